@@ -0,0 +1,349 @@
+// Command migrate rewrites documents written by older versions into the
+// current storage model: ingredient lines live in the ingredients
+// collection with a recipe_id and the parsed form of their quantity, and
+// recipes keep only their ordered ingredient_ids, their timers parsed into
+// timings and their steps upgraded to instructions, and every ingredient
+// points to its food in the catalog. It is safe to run more than once.
+//
+// It reads the same configuration as the server, plus -dry-run to report
+// what would change without writing anything.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/ottolauncher/recipes/config"
+	db "github.com/ottolauncher/recipes/graph/db/mongo"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// legacyRecipe holds every field an older version may have used for the
+// ingredients of a recipe.
+type legacyRecipe struct {
+	ID            primitive.ObjectID   `bson:"_id"`
+	Embedded      []bson.M             `bson:"ingredients"`
+	LegacyIDs     []primitive.ObjectID `bson:"ingredientIDs"`
+	IngredientIDs []primitive.ObjectID `bson:"ingredient_ids"`
+}
+
+type migration struct {
+	recipes     *mongo.Collection
+	ingredients *mongo.Collection
+	foods       *mongo.Collection
+	dryRun      bool
+
+	rewritten, moved, measured, timed, stepped, linked int
+}
+
+func main() {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report the changes without writing them")
+	cfg, _, err := config.LoadFlags(fs, os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Database.Driver != "mongo" {
+		log.Fatalf("nothing to migrate for the %s driver", cfg.Database.Driver)
+	}
+
+	client := db.Init(cfg.Database.URI, cfg.Database.ConnectTimeout)
+	defer client.Disconnect(context.Background())
+	database := client.Database(cfg.Database.Name)
+
+	m := &migration{
+		recipes:     database.Collection("recipes"),
+		ingredients: database.Collection("ingredients"),
+		foods:       database.Collection("foods"),
+		dryRun:      *dryRun,
+	}
+	if err := m.run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	if m.dryRun {
+		log.Printf("dry run: %d recipes and %d embedded ingredients would be rewritten, %d quantities and the timers of %d recipes parsed, the steps of %d recipes upgraded, %d ingredients linked to foods", m.rewritten, m.moved, m.measured, m.timed, m.stepped, m.linked)
+		return
+	}
+	log.Printf("rewrote %d recipes, moved %d embedded ingredients, parsed %d quantities and the timers of %d recipes, upgraded the steps of %d recipes, linked %d ingredients to foods", m.rewritten, m.moved, m.measured, m.timed, m.stepped, m.linked)
+}
+
+// run visits every recipe still in an old shape. Documents already in the
+// current shape do not match the query, which is what makes a second run a
+// no-op.
+func (m *migration) run(ctx context.Context) error {
+	query := bson.M{"$or": bson.A{
+		bson.M{"ingredients": bson.M{"$exists": true}},
+		bson.M{"ingredientIDs": bson.M{"$exists": true}},
+		bson.M{"ingredient_ids": bson.M{"$exists": false}},
+	}}
+	cur, err := m.recipes.Find(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var r legacyRecipe
+		if err := cur.Decode(&r); err != nil {
+			return err
+		}
+		if err := m.recipe(ctx, &r); err != nil {
+			return err
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+
+	if err := m.measure(ctx); err != nil {
+		return err
+	}
+	if err := m.timers(ctx); err != nil {
+		return err
+	}
+	if err := m.steps(ctx); err != nil {
+		return err
+	}
+	if err := m.link(ctx); err != nil {
+		return err
+	}
+
+	// older versions inserted the pagination metadata along with the
+	// documents themselves
+	if !m.dryRun {
+		unset := bson.M{"$unset": bson.M{"pagination": ""}}
+		stale := bson.M{"pagination": bson.M{"$exists": true}}
+		if _, err := m.ingredients.UpdateMany(ctx, stale, unset); err != nil {
+			return err
+		}
+		if _, err := m.recipes.UpdateMany(ctx, stale, unset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recipe moves the embedded ingredients of r into the ingredients
+// collection and rebuilds its ingredient_ids. The order is the one the
+// recipe already recorded, then the embedded lines, then any line that only
+// points back to the recipe.
+func (m *migration) recipe(ctx context.Context, r *legacyRecipe) error {
+	var ids []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	add := func(id primitive.ObjectID) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range r.IngredientIDs {
+		add(id)
+	}
+	for _, id := range r.LegacyIDs {
+		add(id)
+	}
+
+	for _, doc := range r.Embedded {
+		id, ok := doc["_id"].(primitive.ObjectID)
+		if !ok {
+			id = primitive.NewObjectID()
+		}
+		doc["_id"] = id
+		doc["recipe_id"] = r.ID
+		delete(doc, "pagination")
+		if !m.dryRun {
+			_, err := m.ingredients.ReplaceOne(ctx, bson.M{"_id": id}, doc, options.Replace().SetUpsert(true))
+			if err != nil {
+				return err
+			}
+		}
+		add(id)
+		m.moved++
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetProjection(bson.M{"_id": 1})
+	cur, err := m.ingredients.Find(ctx, bson.M{"recipe_id": r.ID}, opts)
+	if err != nil {
+		return err
+	}
+	var owned []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &owned); err != nil {
+		return err
+	}
+	for _, i := range owned {
+		add(i.ID)
+	}
+
+	// ids a recipe recorded for lines that no longer exist are dropped
+	exists := map[primitive.ObjectID]bool{}
+	for _, i := range owned {
+		exists[i.ID] = true
+	}
+	for _, doc := range r.Embedded {
+		exists[doc["_id"].(primitive.ObjectID)] = true
+	}
+	kept := []primitive.ObjectID{}
+	for _, id := range ids {
+		if exists[id] {
+			kept = append(kept, id)
+		}
+	}
+
+	m.rewritten++
+	if m.dryRun {
+		log.Printf("recipe %s: %d embedded ingredients, ingredient_ids %v", r.ID.Hex(), len(r.Embedded), kept)
+		return nil
+	}
+	_, err = m.recipes.UpdateOne(ctx, bson.M{"_id": r.ID}, bson.M{
+		"$set":   bson.M{"ingredient_ids": kept},
+		"$unset": bson.M{"ingredients": "", "ingredientIDs": ""},
+	})
+	return err
+}
+
+// measure stores the structured form of the quantity of every ingredient
+// written before quantities were parsed.
+func (m *migration) measure(ctx context.Context) error {
+	opts := options.Find().SetProjection(bson.M{"quantity": 1})
+	cur, err := m.ingredients.Find(ctx, bson.M{"measure": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var i struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Quantity string             `bson:"quantity"`
+		}
+		if err := cur.Decode(&i); err != nil {
+			return err
+		}
+		m.measured++
+		if m.dryRun {
+			continue
+		}
+		_, err := m.ingredients.UpdateOne(ctx, bson.M{"_id": i.ID}, bson.M{"$set": bson.M{"measure": quantity.Parse(i.Quantity)}})
+		if err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// timers replaces the bare durations older versions stored in timers with
+// timings, COOK timers all of them, and stores the times computed from
+// them so filters and sorts find the recipe. Texts that are no duration
+// stay in timers.
+func (m *migration) timers(ctx context.Context) error {
+	opts := options.Find().SetProjection(bson.M{"timers": 1})
+	cur, err := m.recipes.Find(ctx, bson.M{"timers": bson.M{"$exists": true}, "timings": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var r struct {
+			ID     primitive.ObjectID `bson:"_id"`
+			Timers []string           `bson:"timers"`
+		}
+		if err := cur.Decode(&r); err != nil {
+			return err
+		}
+		m.timed++
+		if m.dryRun {
+			continue
+		}
+		timers := model.NewTimers(r.Timers, nil)
+		prep, cook, total := model.TimerTotals(timers)
+		update := bson.M{
+			"$set":   bson.M{"timings": timers, "prep_time": prep, "cook_time": cook, "total_time": total},
+			"$unset": bson.M{"timers": ""},
+		}
+		// texts such as "overnight" stay as they were written
+		if untimed := model.Untimed(r.Timers); untimed != nil {
+			update = bson.M{"$set": bson.M{"timings": timers, "timers": untimed, "prep_time": prep, "cook_time": cook, "total_time": total}}
+		}
+		_, err := m.recipes.UpdateOne(ctx, bson.M{"_id": r.ID}, update)
+		if err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// steps replaces the plain texts older versions stored in steps with
+// instructions.
+func (m *migration) steps(ctx context.Context) error {
+	opts := options.Find().SetProjection(bson.M{"steps": 1})
+	cur, err := m.recipes.Find(ctx, bson.M{"steps": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var r struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Steps []string           `bson:"steps"`
+		}
+		if err := cur.Decode(&r); err != nil {
+			return err
+		}
+		m.stepped++
+		if m.dryRun {
+			continue
+		}
+		_, err := m.recipes.UpdateOne(ctx, bson.M{"_id": r.ID}, bson.M{
+			"$set":   bson.M{"instructions": model.NewSteps(r.Steps, nil, nil)},
+			"$unset": bson.M{"steps": ""},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// link points every ingredient written before the catalog existed to the
+// food its name stands for, adding the foods the catalog lacks.
+func (m *migration) link(ctx context.Context) error {
+	opts := options.Find().SetProjection(bson.M{"name": 1})
+	cur, err := m.ingredients.Find(ctx, bson.M{"food_id": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var i model.Ingredient
+		if err := cur.Decode(&i); err != nil {
+			return err
+		}
+		m.linked++
+		if m.dryRun {
+			continue
+		}
+		if err := db.LinkFoods(ctx, m.foods, []*model.Ingredient{&i}); err != nil {
+			return err
+		}
+		if i.FoodID == nil {
+			// nothing in the name to match a food on
+			continue
+		}
+		if _, err := m.ingredients.UpdateOne(ctx, bson.M{"_id": i.ID}, bson.M{"$set": bson.M{"food_id": i.FoodID}}); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
@@ -0,0 +1,487 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting the server needs at startup.
+//
+// Values are resolved in layers, each one overriding the previous:
+// built-in defaults, command line flags, the config file and finally
+// environment variables.
+type Config struct {
+	Port      string    `yaml:"port" toml:"port"`
+	Database  Database  `yaml:"database" toml:"database"`
+	CORS      CORS      `yaml:"cors" toml:"cors"`
+	HTTP2     HTTP2     `yaml:"http2" toml:"http2"`
+	Websocket Websocket `yaml:"websocket" toml:"websocket"`
+	Broker    Broker    `yaml:"broker" toml:"broker"`
+	Admin     Admin     `yaml:"admin" toml:"admin"`
+	Units     Units     `yaml:"units" toml:"units"`
+	Auth      Auth      `yaml:"auth" toml:"auth"`
+}
+
+type Database struct {
+	// Driver selects the storage backend, either "mongo" or "memory".
+	Driver         string        `yaml:"driver" toml:"driver"`
+	URI            string        `yaml:"uri" toml:"uri"`
+	Name           string        `yaml:"name" toml:"name"`
+	ConnectTimeout time.Duration `yaml:"connectTimeout" toml:"connectTimeout"`
+	// WriteTimeout, ReadTimeout, QueryTimeout and ListTimeout bound single
+	// writes (and their transactions), single reads, searches and batched
+	// reads, and page listings and bulk writes.
+	WriteTimeout time.Duration `yaml:"writeTimeout" toml:"writeTimeout"`
+	ReadTimeout  time.Duration `yaml:"readTimeout" toml:"readTimeout"`
+	QueryTimeout time.Duration `yaml:"queryTimeout" toml:"queryTimeout"`
+	ListTimeout  time.Duration `yaml:"listTimeout" toml:"listTimeout"`
+	// Watch makes MongoDB change streams, rather than the mutations, the
+	// source of subscription events so writes from other clients show up.
+	Watch bool `yaml:"watch" toml:"watch"`
+}
+
+type CORS struct {
+	AllowOrigins []string `yaml:"allowOrigins" toml:"allowOrigins"`
+}
+
+type HTTP2 struct {
+	MaxConcurrentStreams uint32        `yaml:"maxConcurrentStreams" toml:"maxConcurrentStreams"`
+	MaxReadFrameSize     uint32        `yaml:"maxReadFrameSize" toml:"maxReadFrameSize"`
+	IdleTimeout          time.Duration `yaml:"idleTimeout" toml:"idleTimeout"`
+}
+
+type Websocket struct {
+	KeepAlivePingInterval time.Duration `yaml:"keepAlivePingInterval" toml:"keepAlivePingInterval"`
+}
+
+// Broker selects how subscription events travel between replicas.
+type Broker struct {
+	// Driver is either "memory", for a single process, or "redis".
+	Driver        string `yaml:"driver" toml:"driver"`
+	RedisAddr     string `yaml:"redisAddr" toml:"redisAddr"`
+	RedisPassword string `yaml:"redisPassword" toml:"redisPassword"`
+	RedisDB       int    `yaml:"redisDB" toml:"redisDB"`
+	Channel       string `yaml:"channel" toml:"channel"`
+}
+
+type Admin struct {
+	// RawFilters lets queries pass a raw MongoDB filter through their raw
+	// argument. It bypasses the typed filters, so keep it off in production.
+	RawFilters bool `yaml:"rawFilters" toml:"rawFilters"`
+	// Users are the emails of users who are admins whatever role they are
+	// stored with. List the first admin here; others can be promoted with
+	// the setUserRole mutation.
+	Users []string `yaml:"users" toml:"users"`
+}
+
+type Units struct {
+	// Default is what ingredient quantities are shown in when a request
+	// names no units: "metric", "us" or "original".
+	Default string `yaml:"default" toml:"default"`
+}
+
+type Auth struct {
+	// Secret signs the tokens users get when they log in. Left empty, the
+	// server makes one up at startup and tokens do not survive a restart.
+	Secret string `yaml:"secret" toml:"secret"`
+	// TokenTTL is how long a token stays valid.
+	TokenTTL time.Duration `yaml:"tokenTTL" toml:"tokenTTL"`
+}
+
+func Default() *Config {
+	return &Config{
+		Port: "8080",
+		Database: Database{
+			Driver:         "mongo",
+			URI:            "mongodb://127.0.0.1:27017/recipedb",
+			Name:           "recipedb",
+			ConnectTimeout: 3 * time.Second,
+			WriteTimeout:   350 * time.Millisecond,
+			ReadTimeout:    500 * time.Millisecond,
+			QueryTimeout:   time.Second,
+			ListTimeout:    2 * time.Second,
+		},
+		CORS: CORS{
+			AllowOrigins: []string{"*"},
+		},
+		HTTP2: HTTP2{
+			MaxConcurrentStreams: 250,
+			MaxReadFrameSize:     1048576,
+			IdleTimeout:          10 * time.Second,
+		},
+		Websocket: Websocket{
+			KeepAlivePingInterval: 10 * time.Second,
+		},
+		Broker: Broker{
+			Driver:    "memory",
+			RedisAddr: "127.0.0.1:6379",
+			Channel:   "recipes:events",
+		},
+		Units: Units{
+			Default: "original",
+		},
+		Auth: Auth{
+			TokenTTL: 24 * time.Hour,
+		},
+	}
+}
+
+// setting binds one configuration value to its environment variable and
+// command line flag.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	set   func(c *Config, v string) error
+}
+
+var settings = []setting{
+	{"PORT", "port", "HTTP listen port", func(c *Config, v string) error {
+		c.Port = v
+		return nil
+	}},
+	{"DB_DRIVER", "db-driver", "storage backend: mongo or memory", func(c *Config, v string) error {
+		c.Database.Driver = v
+		return nil
+	}},
+	{"MONGO_URI", "mongo-uri", "MongoDB connection string", func(c *Config, v string) error {
+		c.Database.URI = v
+		return nil
+	}},
+	{"MONGO_DATABASE", "mongo-database", "MongoDB database name", func(c *Config, v string) error {
+		c.Database.Name = v
+		return nil
+	}},
+	{"MONGO_CONNECT_TIMEOUT", "mongo-connect-timeout", "MongoDB connect timeout", func(c *Config, v string) error {
+		return setDuration(&c.Database.ConnectTimeout, v)
+	}},
+	{"MONGO_WRITE_TIMEOUT", "mongo-write-timeout", "MongoDB timeout of single writes", func(c *Config, v string) error {
+		return setDuration(&c.Database.WriteTimeout, v)
+	}},
+	{"MONGO_READ_TIMEOUT", "mongo-read-timeout", "MongoDB timeout of single reads", func(c *Config, v string) error {
+		return setDuration(&c.Database.ReadTimeout, v)
+	}},
+	{"MONGO_QUERY_TIMEOUT", "mongo-query-timeout", "MongoDB timeout of searches and batched reads", func(c *Config, v string) error {
+		return setDuration(&c.Database.QueryTimeout, v)
+	}},
+	{"MONGO_LIST_TIMEOUT", "mongo-list-timeout", "MongoDB timeout of listings and bulk writes", func(c *Config, v string) error {
+		return setDuration(&c.Database.ListTimeout, v)
+	}},
+	{"MONGO_WATCH", "mongo-watch", "publish subscription events from MongoDB change streams", func(c *Config, v string) error {
+		return setBool(&c.Database.Watch, v)
+	}},
+	{"CORS_ALLOW_ORIGINS", "cors-allow-origins", "comma separated list of allowed origins", func(c *Config, v string) error {
+		c.CORS.AllowOrigins = splitList(v)
+		return nil
+	}},
+	{"HTTP2_MAX_CONCURRENT_STREAMS", "http2-max-concurrent-streams", "HTTP/2 max concurrent streams", func(c *Config, v string) error {
+		return setUint32(&c.HTTP2.MaxConcurrentStreams, v)
+	}},
+	{"HTTP2_MAX_READ_FRAME_SIZE", "http2-max-read-frame-size", "HTTP/2 max read frame size in bytes", func(c *Config, v string) error {
+		return setUint32(&c.HTTP2.MaxReadFrameSize, v)
+	}},
+	{"HTTP2_IDLE_TIMEOUT", "http2-idle-timeout", "HTTP/2 idle timeout", func(c *Config, v string) error {
+		return setDuration(&c.HTTP2.IdleTimeout, v)
+	}},
+	{"WS_KEEPALIVE_INTERVAL", "ws-keepalive-interval", "websocket keepalive ping interval", func(c *Config, v string) error {
+		return setDuration(&c.Websocket.KeepAlivePingInterval, v)
+	}},
+	{"BROKER_DRIVER", "broker-driver", "subscription broker: memory or redis", func(c *Config, v string) error {
+		c.Broker.Driver = v
+		return nil
+	}},
+	{"REDIS_ADDR", "redis-addr", "Redis host:port for the broker", func(c *Config, v string) error {
+		c.Broker.RedisAddr = v
+		return nil
+	}},
+	{"REDIS_PASSWORD", "redis-password", "Redis password for the broker", func(c *Config, v string) error {
+		c.Broker.RedisPassword = v
+		return nil
+	}},
+	{"REDIS_DB", "redis-db", "Redis database number for the broker", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		c.Broker.RedisDB = n
+		return nil
+	}},
+	{"BROKER_CHANNEL", "broker-channel", "channel the broker publishes recipe events on", func(c *Config, v string) error {
+		c.Broker.Channel = v
+		return nil
+	}},
+	{"ADMIN_RAW_FILTERS", "admin-raw-filters", "accept raw MongoDB filters in the raw argument", func(c *Config, v string) error {
+		return setBool(&c.Admin.RawFilters, v)
+	}},
+	{"ADMIN_USERS", "admin-users", "comma separated emails of users who are always admins", func(c *Config, v string) error {
+		c.Admin.Users = splitList(v)
+		return nil
+	}},
+	{"UNITS_DEFAULT", "units-default", "units of requests that name none: metric, us or original", func(c *Config, v string) error {
+		c.Units.Default = v
+		return nil
+	}},
+	{"AUTH_SECRET", "auth-secret", "key signing the login tokens, at least 32 bytes", func(c *Config, v string) error {
+		c.Auth.Secret = v
+		return nil
+	}},
+	{"AUTH_TOKEN_TTL", "auth-token-ttl", "how long login tokens stay valid", func(c *Config, v string) error {
+		return setDuration(&c.Auth.TokenTTL, v)
+	}},
+}
+
+// Options are the command line switches that are not configuration values
+// themselves.
+type Options struct {
+	File        string
+	PrintConfig bool
+}
+
+// Load builds the effective configuration from args (usually os.Args[1:]),
+// the config file they or CONFIG_FILE point to, and the environment. The
+// result is validated before it is returned.
+func Load(args []string) (*Config, *Options, error) {
+	return LoadFlags(flag.NewFlagSet("recipes", flag.ContinueOnError), args)
+}
+
+// LoadFlags is Load for commands with switches of their own: they define
+// them on fs before calling it, and read them once it returns.
+func LoadFlags(fs *flag.FlagSet, args []string) (*Config, *Options, error) {
+	c := Default()
+	opts := &Options{}
+
+	fs.StringVar(&opts.File, "config", "", "path to a YAML or TOML config file")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "print the effective configuration and exit")
+	values := map[string]*string{}
+	for _, s := range settings {
+		values[s.flag] = fs.String(s.flag, "", s.usage+" (env "+s.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if e := s.set(c, *values[s.flag]); e != nil {
+					err = fmt.Errorf("flag -%s: %w", s.flag, e)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if v, ok := os.LookupEnv("CONFIG_FILE"); ok && v != "" {
+		opts.File = v
+	}
+	if opts.File != "" {
+		if err := c.readFile(opts.File); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.env); ok && v != "" {
+			if err := s.set(c, v); err != nil {
+				return nil, nil, fmt.Errorf("env %s: %w", s.env, err)
+			}
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return c, opts, nil
+}
+
+func (c *Config) readFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, c)
+	case ".toml":
+		err = toml.Unmarshal(raw, c)
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once so a bad deployment fails
+// fast with a complete list of what to fix.
+func (c *Config) Validate() error {
+	var errs []string
+
+	if p, err := strconv.Atoi(c.Port); err != nil || p < 1 || p > 65535 {
+		errs = append(errs, fmt.Sprintf("port %q must be a number between 1 and 65535", c.Port))
+	}
+
+	switch c.Database.Driver {
+	case "memory":
+		if c.Database.Watch {
+			errs = append(errs, "database.watch needs the mongo driver")
+		}
+	case "mongo":
+		u, err := url.Parse(c.Database.URI)
+		if err != nil || (u.Scheme != "mongodb" && u.Scheme != "mongodb+srv") {
+			errs = append(errs, "database.uri must be a mongodb:// or mongodb+srv:// connection string")
+		}
+		if c.Database.Name == "" {
+			errs = append(errs, "database.name must not be empty")
+		}
+		if c.Database.ConnectTimeout <= 0 {
+			errs = append(errs, "database.connectTimeout must be positive")
+		}
+		if c.Database.WriteTimeout <= 0 || c.Database.ReadTimeout <= 0 || c.Database.QueryTimeout <= 0 || c.Database.ListTimeout <= 0 {
+			errs = append(errs, "database.writeTimeout, readTimeout, queryTimeout and listTimeout must be positive")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("database.driver %q must be mongo or memory", c.Database.Driver))
+	}
+
+	if len(c.CORS.AllowOrigins) == 0 {
+		errs = append(errs, "cors.allowOrigins must list at least one origin")
+	}
+
+	if c.HTTP2.MaxConcurrentStreams == 0 {
+		errs = append(errs, "http2.maxConcurrentStreams must be positive")
+	}
+	// bounds from RFC 7540 section 4.2
+	if c.HTTP2.MaxReadFrameSize < 16384 || c.HTTP2.MaxReadFrameSize > 16777215 {
+		errs = append(errs, "http2.maxReadFrameSize must be between 16384 and 16777215")
+	}
+	if c.HTTP2.IdleTimeout <= 0 {
+		errs = append(errs, "http2.idleTimeout must be positive")
+	}
+	if c.Websocket.KeepAlivePingInterval <= 0 {
+		errs = append(errs, "websocket.keepAlivePingInterval must be positive")
+	}
+
+	switch c.Broker.Driver {
+	case "memory":
+	case "redis":
+		if c.Broker.RedisAddr == "" {
+			errs = append(errs, "broker.redisAddr must not be empty")
+		}
+		if c.Broker.RedisDB < 0 {
+			errs = append(errs, "broker.redisDB must not be negative")
+		}
+		if c.Broker.Channel == "" {
+			errs = append(errs, "broker.channel must not be empty")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("broker.driver %q must be memory or redis", c.Broker.Driver))
+	}
+	// every replica tails the change streams itself, sharing the events
+	// through Redis as well would deliver each of them once per replica
+	if c.Database.Watch && c.Broker.Driver != "memory" {
+		errs = append(errs, "database.watch needs broker.driver memory")
+	}
+
+	switch strings.ToLower(c.Units.Default) {
+	case "metric", "us", "original":
+	default:
+		errs = append(errs, fmt.Sprintf("units.default %q must be metric, us or original", c.Units.Default))
+	}
+
+	if c.Auth.Secret != "" && len(c.Auth.Secret) < 32 {
+		errs = append(errs, "auth.secret must be at least 32 bytes long")
+	}
+	if c.Auth.TokenTTL <= 0 {
+		errs = append(errs, "auth.tokenTTL must be positive")
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// Redacted returns a copy of c that is safe to print: credentials embedded
+// in connection strings are masked.
+func (c *Config) Redacted() *Config {
+	r := *c
+	r.CORS.AllowOrigins = append([]string(nil), c.CORS.AllowOrigins...)
+	r.Admin.Users = append([]string(nil), c.Admin.Users...)
+	r.Database.URI = redactURI(c.Database.URI)
+	if r.Broker.RedisPassword != "" {
+		r.Broker.RedisPassword = "xxxxx"
+	}
+	if r.Auth.Secret != "" {
+		r.Auth.Secret = "xxxxx"
+	}
+	return &r
+}
+
+// String renders the redacted configuration as YAML.
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+func redactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "xxxxx"
+	}
+	return u.Redacted()
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func setDuration(d *time.Duration, v string) error {
+	p, err := time.ParseDuration(v)
+	if err != nil {
+		return err
+	}
+	*d = p
+	return nil
+}
+
+func setBool(b *bool, v string) error {
+	p, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	*b = p
+	return nil
+}
+
+func setUint32(n *uint32, v string) error {
+	p, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return err
+	}
+	*n = uint32(p)
+	return nil
+}
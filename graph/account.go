@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+)
+
+// register creates the account in, which validateRegister has checked, and
+// signs it in.
+func (r *Resolver) register(ctx context.Context, in *model.Register) (*model.AuthPayload, error) {
+	hash, err := auth.HashPassword(in.Password)
+	if err != nil {
+		return nil, err
+	}
+	user, err := r.UM.Create(ctx, &model.User{Email: in.Email, Name: in.Name, Role: model.RoleUser, PasswordHash: hash})
+	if err != nil {
+		return nil, err
+	}
+	return r.signIn(user)
+}
+
+// noAccount is a bcrypt hash, at the cost passwords are hashed with, that
+// login checks passwords against when there is no account for the email.
+var noAccount = []byte("$2a$10$iX71Vf/v.7adoXRYLjMYDe215MuQ6Bg5Om8cERj59zzaGQ.G2NwR2")
+
+// login signs in the user in names. Unknown emails and wrong passwords fail
+// alike, and take as long, so neither the error nor the time it takes tells
+// which emails are registered.
+func (r *Resolver) login(ctx context.Context, in *model.Login) (*model.AuthPayload, error) {
+	user, err := r.UM.ByEmail(ctx, in.Email)
+	if err != nil && apperr.From(err).Code != apperr.NotFound {
+		return nil, err
+	}
+	hash := noAccount
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	if !auth.CheckPassword(hash, in.Password) || user == nil {
+		return nil, apperr.New(apperr.Unauthenticated, "email or password is incorrect")
+	}
+	return r.signIn(user)
+}
+
+func (r *Resolver) signIn(user *model.User) (*model.AuthPayload, error) {
+	token, err := r.Auth.Issue(user)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AuthPayload{Token: &token, User: user, Errors: []*model.UserError{}}, nil
+}
+
+// role returns the role u acts with: admin when stored so or listed in
+// Admins, a plain user otherwise.
+func (r *Resolver) role(u *model.User) model.Role {
+	if u.Role == model.RoleAdmin {
+		return model.RoleAdmin
+	}
+	for _, email := range r.Admins {
+		if model.NormalizeEmail(email) == u.Email {
+			return model.RoleAdmin
+		}
+	}
+	return model.RoleUser
+}
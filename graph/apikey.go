@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// KeyFields turns requests made with an API key away from the queries,
+// mutations and subscriptions that do not name the scope they need with
+// @scope, so that keys reach nothing their scopes do not cover.
+// Introspection stays open to them.
+func KeyFields(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if auth.KeyForContext(ctx) == nil || strings.HasPrefix(fc.Field.Name, "__") {
+		return next(ctx)
+	}
+	switch fc.Object {
+	case "Query", "Mutation", "Subscription":
+		if fc.Field.Definition.Directives.ForName("scope") == nil {
+			return nil, apperr.New(apperr.Forbidden, "API keys cannot use %s", fc.Field.Name)
+		}
+	}
+	return next(ctx)
+}
+
+// issueAPIKey creates the key in, which validateNewAPIKey has checked, on
+// behalf of the admin signed in.
+func (r *Resolver) issueAPIKey(ctx context.Context, in *model.NewAPIKey) (*model.APIKeyPayload, error) {
+	id := primitive.NewObjectID()
+	key, hash, err := auth.NewKey(id)
+	if err != nil {
+		return nil, err
+	}
+	created, err := r.AK.Create(ctx, &model.APIKey{ID: id, Name: in.Name, Scopes: in.Scopes, Hash: hash, CreatedBy: auth.UserID(ctx)})
+	if err != nil {
+		return nil, err
+	}
+	return &model.APIKeyPayload{APIKey: created, Key: &key, Errors: []*model.UserError{}}, nil
+}
+
+// rotateAPIKey gives the key with the given ID a new secret. Revoked keys
+// stay revoked.
+func (r *Resolver) rotateAPIKey(ctx context.Context, id string) (*model.APIKeyPayload, error) {
+	if _, err := r.liveAPIKey(ctx, id); err != nil {
+		return nil, err
+	}
+	oid, _ := primitive.ObjectIDFromHex(id)
+	key, hash, err := auth.NewKey(oid)
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := r.AK.Rotate(ctx, id, hash, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &model.APIKeyPayload{APIKey: rotated, Key: &key, Errors: []*model.UserError{}}, nil
+}
+
+func (r *Resolver) revokeAPIKey(ctx context.Context, id string) (*model.APIKeyPayload, error) {
+	if _, err := r.liveAPIKey(ctx, id); err != nil {
+		return nil, err
+	}
+	revoked, err := r.AK.Revoke(ctx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &model.APIKeyPayload{APIKey: revoked, Errors: []*model.UserError{}}, nil
+}
+
+// liveAPIKey returns the key with the given ID, failing with a conflict
+// when it was revoked.
+func (r *Resolver) liveAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	key, err := r.AK.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, &apperr.Error{Code: apperr.Conflict, Message: "key " + id + " is revoked", Field: []string{"id"}}
+	}
+	return key, nil
+}
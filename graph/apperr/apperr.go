@@ -0,0 +1,64 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Code is the machine readable kind of an error, reported to clients in the
+// "code" extension of GraphQL errors and in mutation payloads.
+type Code string
+
+const (
+	NotFound   Code = "NOT_FOUND"
+	Validation Code = "VALIDATION"
+	Conflict   Code = "CONFLICT"
+	// Unauthenticated is for requests that need a user and carry none, or
+	// credentials that do not match.
+	Unauthenticated Code = "UNAUTHENTICATED"
+	Forbidden       Code = "FORBIDDEN"
+	Internal        Code = "INTERNAL"
+)
+
+type Error struct {
+	Code    Code
+	Message string
+	// Field is the input path the error is about, if any.
+	Field []string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalid reports a validation error about the input field at path.
+func Invalid(path []string, format string, args ...interface{}) *Error {
+	return &Error{Code: Validation, Message: fmt.Sprintf(format, args...), Field: path}
+}
+
+// From classifies err, including the sentinel errors of the storage
+// backends, so every failure reaching a client carries a code. Errors it
+// does not recognise are Internal.
+func From(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return &Error{Code: NotFound, Message: "not found", Err: err}
+	case mongo.IsDuplicateKeyError(err):
+		return &Error{Code: Conflict, Message: "already exists", Err: err}
+	}
+	return &Error{Code: Internal, Message: err.Error(), Err: err}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// KeyHeader is the header partner applications send their API key in.
+const KeyHeader = "X-API-Key"
+
+// keyPrefix starts every API key, so leaked keys are easy to search for.
+const keyPrefix = "rk_"
+
+type keyContextKey struct{}
+
+// WithKey returns a copy of ctx carrying k.
+func WithKey(ctx context.Context, k *model.APIKey) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, k)
+}
+
+// KeyForContext returns the API key a request was made with, nil for
+// requests without one.
+func KeyForContext(ctx context.Context) *model.APIKey {
+	k, _ := ctx.Value(keyContextKey{}).(*model.APIKey)
+	return k
+}
+
+// NewKey returns a fresh key for the API key with the given ID, and the
+// hash to store for it. The key is only ever shown to whoever asked for it.
+func NewKey(id primitive.ObjectID) (string, []byte, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	return keyPrefix + id.Hex() + "_" + encoded, hashSecret(encoded), nil
+}
+
+// splitKey returns the ID and the secret key is made of.
+func splitKey(key string) (string, string, bool) {
+	rest := strings.TrimPrefix(key, keyPrefix)
+	if len(rest) == len(key) || len(rest) < 26 || rest[24] != '_' {
+		return "", "", false
+	}
+	return rest[:24], rest[25:], true
+}
+
+func hashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// Keys finds the API keys requests are made with and records their use.
+type Keys interface {
+	Get(ctx context.Context, id string) (*model.APIKey, error)
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
+
+// KeyMiddleware puts the API key of requests sending one in KeyHeader on
+// their context, and counts the request against it. Requests without the
+// header go through untouched; unknown, revoked or wrong keys are turned
+// away with 401.
+func KeyMiddleware(keys Keys) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(KeyHeader)
+			if header == "" {
+				return next(c)
+			}
+			id, secret, ok := splitKey(header)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
+			}
+			ctx := c.Request().Context()
+			k, err := keys.Get(ctx, id)
+			if err != nil {
+				if code := apperr.From(err).Code; code == apperr.NotFound || code == apperr.Validation {
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
+				}
+				return err
+			}
+			if k.RevokedAt != nil || subtle.ConstantTimeCompare(k.Hash, hashSecret(secret)) != 1 {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid API key")
+			}
+			// the request is served even when its use cannot be recorded
+			if err := keys.Touch(ctx, k.ID, time.Now()); err != nil {
+				log.Printf("recording use of API key %s: %v", id, err)
+			}
+			c.SetRequest(c.Request().WithContext(WithKey(ctx, k)))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package auth signs users in: it hashes their passwords, issues the tokens
+// they send back in the Authorization header, and puts the user a request
+// carries on its context.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength is the fewest characters a password may have.
+const MinPasswordLength = 8
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying u.
+func WithUser(ctx context.Context, u *model.User) context.Context {
+	return context.WithValue(ctx, contextKey{}, u)
+}
+
+// ForContext returns the user signed in on ctx, nil for anonymous requests.
+func ForContext(ctx context.Context) *model.User {
+	u, _ := ctx.Value(contextKey{}).(*model.User)
+	return u
+}
+
+// UserID returns the ID of the user signed in on ctx, which writes are
+// stamped with. Requests made with an API key alone act for the user who
+// issued it. It is nil for anonymous requests.
+func UserID(ctx context.Context) *primitive.ObjectID {
+	if u := ForContext(ctx); u != nil {
+		id := u.ID
+		return &id
+	}
+	if k := KeyForContext(ctx); k != nil && k.CreatedBy != nil {
+		id := *k.CreatedBy
+		return &id
+	}
+	return nil
+}
+
+func HashPassword(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// CheckPassword reports whether password is the one hash was made from.
+func CheckPassword(hash []byte, password string) bool {
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+}
+
+// Users finds the user a token was issued to.
+type Users interface {
+	Get(ctx context.Context, id string) (*model.User, error)
+}
+
+// Issuer signs tokens with Secret that stay valid for TTL.
+type Issuer struct {
+	Secret []byte
+	TTL    time.Duration
+}
+
+// Issue returns a token for u.
+func (i *Issuer) Issue(u *model.User) (string, error) {
+	now := time.Now()
+	claims := &jwt.StandardClaims{
+		Subject:   u.ID.Hex(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(i.TTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.Secret)
+}
+
+// Middleware signs in the user of requests sending a bearer token, loading
+// them from users. Requests without an Authorization header go through
+// anonymously; invalid or expired tokens, or tokens of users that no longer
+// exist, are turned away with 401.
+func (i *Issuer) Middleware(users Users) echo.MiddlewareFunc {
+	verify := middleware.JWTWithConfig(middleware.JWTConfig{
+		Skipper: func(c echo.Context) bool {
+			return c.Request().Header.Get(echo.HeaderAuthorization) == ""
+		},
+		SigningKey: i.Secret,
+		Claims:     &jwt.StandardClaims{},
+	})
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return verify(func(c echo.Context) error {
+			token, ok := c.Get("user").(*jwt.Token)
+			if !ok {
+				return next(c)
+			}
+			claims := token.Claims.(*jwt.StandardClaims)
+			ctx := c.Request().Context()
+			u, err := users.Get(ctx, claims.Subject)
+			if err != nil {
+				if code := apperr.From(err).Code; code == apperr.NotFound || code == apperr.Validation {
+					return echo.NewHTTPError(http.StatusUnauthorized, "unknown user")
+				}
+				return err
+			}
+			c.SetRequest(c.Request().WithContext(WithUser(ctx, u)))
+			return next(c)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxPageSize bounds first on connection queries.
+const maxPageSize = 100
+
+const (
+	recipeCursor     = "recipe"
+	ingredientCursor = "ingredient"
+)
+
+// Cursors are opaque to clients. They hold the kind of node, which search
+// connections need since they walk recipes then ingredients, and the node
+// ID the next page starts after.
+func encodeCursor(kind string, id primitive.ObjectID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(kind + ":" + id.Hex()))
+}
+
+func decodeCursor(cursor *string) (string, *primitive.ObjectID, error) {
+	if cursor == nil {
+		return "", nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(*cursor)
+	if err != nil {
+		return "", nil, apperr.Invalid([]string{"after"}, "invalid cursor")
+	}
+	kind, hex, ok := strings.Cut(string(raw), ":")
+	if !ok || (kind != recipeCursor && kind != ingredientCursor) {
+		return "", nil, apperr.Invalid([]string{"after"}, "invalid cursor")
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return "", nil, apperr.Invalid([]string{"after"}, "invalid cursor")
+	}
+	return kind, &id, nil
+}
+
+func pageSize(first *int) (int, error) {
+	if first == nil {
+		return 12, nil
+	}
+	if *first < 0 || *first > maxPageSize {
+		return 0, apperr.Invalid([]string{"first"}, "first must be between 0 and %d", maxPageSize)
+	}
+	return *first, nil
+}
+
+func pageInfo(cursors []string, after *string, more bool) *model.PageInfo {
+	info := &model.PageInfo{
+		HasNextPage:     more,
+		HasPreviousPage: after != nil,
+	}
+	if len(cursors) > 0 {
+		info.StartCursor = &cursors[0]
+		info.EndCursor = &cursors[len(cursors)-1]
+	}
+	return info
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/text"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// cookableCandidates caps how many recipes using the pantry are ranked.
+const cookableCandidates = 500
+
+// cookableRecipes ranks the recipes using at least one food of the pantry
+// by how many of their foods it has. Expired items count as missing, and
+// items expiring within expiringDays count twice so that recipes using them
+// up come first. Recipes missing more than maxMissing foods are left out.
+func (r *Resolver) cookableRecipes(ctx context.Context, pantryID string, maxMissing, expiringDays, limit int) ([]*model.CookableRecipe, error) {
+	switch {
+	case maxMissing < 0:
+		return nil, apperr.Invalid([]string{"maxMissing"}, "maxMissing must not be negative")
+	case expiringDays < 0:
+		return nil, apperr.Invalid([]string{"expiringDays"}, "expiringDays must not be negative")
+	case limit < 0:
+		return nil, apperr.Invalid([]string{"limit"}, "limit must not be negative")
+	}
+
+	pantry, err := r.PM.Get(ctx, pantryID)
+	if err != nil {
+		e := *apperr.From(err)
+		if e.Code == apperr.Internal {
+			return nil, err
+		}
+		e.Field = []string{"pantryID"}
+		return nil, &e
+	}
+
+	now := time.Now()
+	soon := now.AddDate(0, 0, expiringDays)
+	onHand := map[primitive.ObjectID][]*model.PantryItem{}
+	var ids []primitive.ObjectID
+	for _, i := range pantry.Items {
+		if i.FoodID == nil || i.Expired(now) {
+			continue
+		}
+		if _, ok := onHand[*i.FoodID]; !ok {
+			ids = append(ids, *i.FoodID)
+		}
+		onHand[*i.FoodID] = append(onHand[*i.FoodID], i)
+	}
+	cookable := []*model.CookableRecipe{}
+	if len(ids) == 0 {
+		return cookable, nil
+	}
+
+	recipes, err := r.RM.All(ctx, &model.RecipeFilter{FoodIDs: ids}, nil, nil, cookableCandidates, 1)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	for _, recipe := range recipes {
+		c := &model.CookableRecipe{Recipe: recipe, Have: []*model.Ingredient{}, Missing: []*model.Ingredient{}, Expiring: []*model.PantryItem{}}
+		have, missing := map[string]bool{}, map[string]bool{}
+		for _, line := range recipe.Ingredients {
+			if line.FoodID == nil {
+				// a line outside the catalog can't be on hand
+				missing["name:"+text.Slugify(line.Name)] = true
+				c.Missing = append(c.Missing, line)
+				continue
+			}
+			key := line.FoodID.Hex()
+			items, ok := onHand[*line.FoodID]
+			if !ok {
+				missing[key] = true
+				c.Missing = append(c.Missing, line)
+				continue
+			}
+			c.Have = append(c.Have, line)
+			if have[key] {
+				continue
+			}
+			have[key] = true
+			c.Score++
+			for _, i := range items {
+				if i.ExpiresAt != nil && i.ExpiresAt.Before(soon) {
+					c.Score++
+					c.Expiring = append(c.Expiring, i)
+				}
+			}
+		}
+		if len(have) == 0 || len(missing) > maxMissing {
+			continue
+		}
+		c.Coverage = float64(len(have)) / float64(len(have)+len(missing))
+		cookable = append(cookable, c)
+	}
+
+	sort.SliceStable(cookable, func(a, b int) bool {
+		x, y := cookable[a], cookable[b]
+		switch {
+		case x.Score != y.Score:
+			return x.Score > y.Score
+		case x.Coverage != y.Coverage:
+			return x.Coverage > y.Coverage
+		case len(x.Missing) != len(y.Missing):
+			return len(x.Missing) < len(y.Missing)
+		}
+		return x.Recipe.Name < y.Recipe.Name
+	})
+	if len(cookable) > limit {
+		cookable = cookable[:limit]
+	}
+	return cookable, nil
+}
@@ -0,0 +1,666 @@
+// Package dbtest is the behavior every storage backend promises. The tests
+// of each backend run it against stores of their own, so the in-memory one
+// keeps behaving like MongoDB.
+package dbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	db "github.com/ottolauncher/recipes/graph/db/mongo"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Backend is the set of managers a backend provides, all over one store.
+type Backend struct {
+	Recipes     db.IRecipe
+	Ingredients db.Ingredient
+	Foods       db.Food
+	Lists       db.ShoppingList
+	Pantries    db.Pantry
+	Plans       db.MealPlan
+	Users       db.User
+	Keys        db.APIKey
+}
+
+// Run runs the suite. open returns a backend over an empty store, one per
+// test.
+func Run(t *testing.T, open func(t *testing.T) *Backend) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, b *Backend)
+	}{
+		{"RecipeLifecycle", testRecipeLifecycle},
+		{"RecipeUpdateKeepsLines", testRecipeUpdateKeepsLines},
+		{"RecipeTimers", testRecipeTimers},
+		{"RecipeFields", testRecipeFields},
+		{"RecipeErrors", testRecipeErrors},
+		{"RecipePages", testRecipePages},
+		{"RecipeStamps", testRecipeStamps},
+		{"RecipeModerate", testRecipeModerate},
+		{"IngredientDeleteUnlinks", testIngredientDeleteUnlinks},
+		{"Foods", testFoods},
+		{"ShoppingLists", testShoppingLists},
+		{"Pantries", testPantries},
+		{"MealPlans", testMealPlans},
+		{"Users", testUsers},
+		{"APIKeys", testAPIKeys},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, open(t))
+		})
+	}
+}
+
+func newRecipe(name string, lines ...string) *model.NewRecipe {
+	r := &model.NewRecipe{Name: name, Ingredients: []*model.NewIngredient{}}
+	for _, l := range lines {
+		r.Ingredients = append(r.Ingredients, &model.NewIngredient{Name: l, Type: "x", Quantity: "1"})
+	}
+	return r
+}
+
+func byID(id primitive.ObjectID) *model.RecipeFilter {
+	hex := id.Hex()
+	return &model.RecipeFilter{ID: &hex}
+}
+
+func wantCode(t *testing.T, err error, code apperr.Code) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("got no error, want %s", code)
+	}
+	if got := apperr.From(err).Code; got != code {
+		t.Fatalf("got %s (%v), want %s", got, err, code)
+	}
+}
+
+func names(ingredients []*model.Ingredient) []string {
+	out := []string{}
+	for _, i := range ingredients {
+		out = append(out, i.Name)
+	}
+	return out
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for n := range a {
+		if a[n] != b[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func testRecipeLifecycle(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	created, err := b.Recipes.Create(ctx, newRecipe("Tomato Soup", "tomato", "onion", "salt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Slug == nil || *created.Slug != "tomato-soup" {
+		t.Errorf("slug = %v, want tomato-soup", created.Slug)
+	}
+	if got := names(created.Ingredients); !sameStrings(got, []string{"tomato", "onion", "salt"}) {
+		t.Errorf("created lines = %q", got)
+	}
+
+	got, err := b.Recipes.Get(ctx, byID(created.ID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Tomato Soup" || !sameStrings(names(got.Ingredients), []string{"tomato", "onion", "salt"}) {
+		t.Errorf("Get = %q with %q", got.Name, names(got.Ingredients))
+	}
+	for _, i := range got.Ingredients {
+		if i.RecipeID != created.ID {
+			t.Errorf("line %q points to recipe %s", i.Name, i.RecipeID.Hex())
+		}
+	}
+
+	lines, err := b.Ingredients.ByRecipes(ctx, []primitive.ObjectID{created.ID})
+	if err != nil || len(lines) != 3 {
+		t.Errorf("ByRecipes = %d lines, %v, want 3", len(lines), err)
+	}
+
+	deleted, err := b.Recipes.Delete(ctx, byID(created.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.ID != created.ID {
+		t.Errorf("deleted %s, want %s", deleted.ID.Hex(), created.ID.Hex())
+	}
+	_, err = b.Recipes.Get(ctx, byID(created.ID), nil)
+	wantCode(t, err, apperr.NotFound)
+	recipeID := created.ID.Hex()
+	_, err = b.Ingredients.Get(ctx, &model.IngredientFilter{RecipeID: &recipeID}, nil)
+	wantCode(t, err, apperr.NotFound)
+}
+
+func testRecipeUpdateKeepsLines(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	created, err := b.Recipes.Create(ctx, newRecipe("Omelette", "egg", "milk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	egg, milk := created.Ingredients[0], created.Ingredients[1]
+
+	updated, err := b.Recipes.Update(ctx, &model.UpdateRecipe{
+		ID:   created.ID.Hex(),
+		Name: "Cheese Omelette",
+		Ingredients: []*model.UpdateIngredient{
+			{ID: "new", Name: "cheese", Type: "x", Quantity: "50 g"},
+			{ID: egg.ID.Hex(), Name: "egg", Type: "x", Quantity: "3"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Cheese Omelette" || updated.Slug == nil || *updated.Slug != "cheese-omelette" {
+		t.Errorf("updated %q, slug %v", updated.Name, updated.Slug)
+	}
+	if got := names(updated.Ingredients); !sameStrings(got, []string{"cheese", "egg"}) {
+		t.Fatalf("lines = %q, want [cheese egg]", got)
+	}
+	if updated.Ingredients[1].ID != egg.ID {
+		t.Errorf("egg line got a new ID")
+	}
+	if m := updated.Ingredients[0].Measure; m == nil || m.Unit != "g" {
+		t.Errorf("new line measure = %+v, want grams", m)
+	}
+	milkID := milk.ID.Hex()
+	_, err = b.Ingredients.Get(ctx, &model.IngredientFilter{ID: &milkID}, nil)
+	wantCode(t, err, apperr.NotFound)
+
+	_, err = b.Recipes.Update(ctx, &model.UpdateRecipe{ID: primitive.NewObjectID().Hex(), Name: "x"})
+	wantCode(t, err, apperr.NotFound)
+}
+
+func testRecipeTimers(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	in := newRecipe("Bread")
+	in.Timers = []string{"overnight", "45 min", "30 mins."}
+	created, err := b.Recipes.Create(ctx, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Recipes.Get(ctx, byID(created.ID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timed := got.Timed()
+	if len(timed) != 1 || timed[0].Seconds != 45*60 || got.CookTime == nil || *got.CookTime != 45*60 {
+		t.Errorf("timings = %d, cook time %v, want 45 min", len(timed), got.CookTime)
+	}
+	if !sameStrings(got.Timers, []string{"overnight", "30 mins."}) {
+		t.Errorf("timers = %q, want the texts that are no duration", got.Timers)
+	}
+
+	updated, err := b.Recipes.Update(ctx, &model.UpdateRecipe{ID: created.ID.Hex(), Name: "Bread", Timers: []string{"1 hour"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Timers) != 0 || len(updated.Timed()) != 1 {
+		t.Errorf("after update: timers %q, %d timings", updated.Timers, len(updated.Timed()))
+	}
+
+	updated, err = b.Recipes.Update(ctx, &model.UpdateRecipe{ID: created.ID.Hex(), Name: "Bread", Timers: []string{"overnight", "1 hour"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameStrings(updated.Timers, []string{"overnight"}) || len(updated.Timed()) != 1 {
+		t.Errorf("after update: timers %q, %d timings", updated.Timers, len(updated.Timed()))
+	}
+}
+
+func testRecipeFields(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	created, err := b.Recipes.Create(ctx, newRecipe("Pancakes", "flour"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Recipes.Get(ctx, byID(created.ID), []string{"name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Pancakes" {
+		t.Errorf("name = %q", got.Name)
+	}
+	if got.Ingredients != nil {
+		t.Errorf("ingredients joined although not selected: %q", names(got.Ingredients))
+	}
+	got, err = b.Recipes.Get(ctx, byID(created.ID), []string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != created.ID || got.Ingredients != nil {
+		t.Errorf("reading no fields gave %s with %q", got.ID.Hex(), names(got.Ingredients))
+	}
+	got, err = b.Recipes.Get(ctx, byID(created.ID), []string{"name", "ingredients"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameStrings(names(got.Ingredients), []string{"flour"}) {
+		t.Errorf("ingredients = %q, want [flour]", names(got.Ingredients))
+	}
+
+	found, err := b.Recipes.ByIDs(ctx, []primitive.ObjectID{created.ID, primitive.NewObjectID()})
+	if err != nil || len(found) != 1 || found[0].ID != created.ID {
+		t.Errorf("ByIDs = %d recipes, %v, want the one known", len(found), err)
+	}
+}
+
+func testRecipeErrors(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	bad := "nope"
+	_, err := b.Recipes.Get(ctx, &model.RecipeFilter{ID: &bad}, nil)
+	wantCode(t, err, apperr.Validation)
+	_, err = b.Recipes.Get(ctx, byID(primitive.NewObjectID()), nil)
+	wantCode(t, err, apperr.NotFound)
+	_, err = b.Recipes.Delete(ctx, byID(primitive.NewObjectID()))
+	wantCode(t, err, apperr.NotFound)
+	_, err = b.Recipes.Update(ctx, &model.UpdateRecipe{ID: bad, Name: "x"})
+	wantCode(t, err, apperr.Validation)
+}
+
+func testRecipePages(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	for _, name := range []string{"Apple Pie", "Banana Bread", "Cherry Tart"} {
+		if _, err := b.Recipes.Create(ctx, newRecipe(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := b.Recipes.All(ctx, nil, nil, nil, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 {
+		t.Fatalf("page 1 has %d recipes, want 2", len(page))
+	}
+	if p := page[0].Pagination; p.Total != 3 || p.TotalPage != 2 || p.Page != 1 || p.Next != 2 {
+		t.Errorf("pagination = %+v", p)
+	}
+	page, err = b.Recipes.All(ctx, nil, nil, nil, 2, 2)
+	if err != nil || len(page) != 1 {
+		t.Errorf("page 2 = %d recipes, %v, want 1", len(page), err)
+	}
+
+	eq := "Banana Bread"
+	page, err = b.Recipes.All(ctx, &model.RecipeFilter{Name: &model.StringFilter{Eq: &eq}}, nil, nil, 12, 1)
+	if err != nil || len(page) != 1 || page[0].Name != eq {
+		t.Errorf("filtered = %d recipes, %v", len(page), err)
+	}
+	missing := "Durian"
+	_, err = b.Recipes.All(ctx, &model.RecipeFilter{Name: &model.StringFilter{Eq: &missing}}, nil, nil, 12, 1)
+	wantCode(t, err, apperr.NotFound)
+
+	first, more, err := b.Recipes.AllAfter(ctx, nil, nil, 2, nil)
+	if err != nil || len(first) != 2 || !more {
+		t.Fatalf("AllAfter = %d recipes, more %v, %v", len(first), more, err)
+	}
+	last := first[1].ID
+	rest, more, err := b.Recipes.AllAfter(ctx, nil, nil, 2, &last)
+	if err != nil || len(rest) != 1 || more {
+		t.Errorf("AllAfter the rest = %d recipes, more %v, %v", len(rest), more, err)
+	}
+	for _, r := range first {
+		if len(rest) > 0 && r.ID == rest[0].ID {
+			t.Errorf("recipe %s on both pages", r.ID.Hex())
+		}
+	}
+}
+
+func testRecipeStamps(t *testing.T, b *Backend) {
+	alice := &model.User{ID: primitive.NewObjectID()}
+	bob := &model.User{ID: primitive.NewObjectID()}
+	created, err := b.Recipes.Create(auth.WithUser(context.Background(), alice), newRecipe("Stew", "beef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.CreatedBy == nil || *created.CreatedBy != alice.ID {
+		t.Fatalf("created by %v, want alice", created.CreatedBy)
+	}
+	line := created.Ingredients[0]
+	updated, err := b.Recipes.Update(auth.WithUser(context.Background(), bob), &model.UpdateRecipe{
+		ID:          created.ID.Hex(),
+		Name:        "Beef Stew",
+		Ingredients: []*model.UpdateIngredient{{ID: line.ID.Hex(), Name: "beef", Type: "x", Quantity: "1 kg"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.CreatedBy == nil || *updated.CreatedBy != alice.ID {
+		t.Errorf("update changed the author to %v", updated.CreatedBy)
+	}
+	if updated.UpdatedBy == nil || *updated.UpdatedBy != bob.ID {
+		t.Errorf("updated by %v, want bob", updated.UpdatedBy)
+	}
+	if kept := updated.Ingredients[0]; kept.CreatedBy == nil || *kept.CreatedBy != alice.ID {
+		t.Errorf("kept line created by %v, want alice", kept.CreatedBy)
+	}
+
+	anonymous, err := b.Recipes.Create(context.Background(), newRecipe("Salad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anonymous.CreatedBy != nil {
+		t.Errorf("anonymous recipe created by %v", anonymous.CreatedBy)
+	}
+}
+
+func testRecipeModerate(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	created, err := b.Recipes.Create(ctx, newRecipe("Stew", "beef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := "copied from a book"
+	locked, err := b.Recipes.Moderate(ctx, &model.ModerateRecipe{ID: created.ID.Hex(), Locked: true, Note: &note})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !locked.Locked || locked.ModerationNote == nil || *locked.ModerationNote != note {
+		t.Errorf("moderated = locked %v, note %v", locked.Locked, locked.ModerationNote)
+	}
+	got, err := b.Recipes.Get(ctx, byID(created.ID), []string{"locked"})
+	if err != nil || !got.Locked {
+		t.Errorf("Get after locking = %v, %v", got, err)
+	}
+	unlocked, err := b.Recipes.Moderate(ctx, &model.ModerateRecipe{ID: created.ID.Hex()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unlocked.Locked || unlocked.ModerationNote != nil {
+		t.Errorf("unlocked = locked %v, note %v", unlocked.Locked, unlocked.ModerationNote)
+	}
+	_, err = b.Recipes.Moderate(ctx, &model.ModerateRecipe{ID: primitive.NewObjectID().Hex(), Locked: true})
+	wantCode(t, err, apperr.NotFound)
+}
+
+func testIngredientDeleteUnlinks(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	created, err := b.Recipes.Create(ctx, newRecipe("Toast", "bread", "butter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	butter := created.Ingredients[1].ID.Hex()
+	if _, err := b.Ingredients.Delete(ctx, &model.IngredientFilter{ID: &butter}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Recipes.Get(ctx, byID(created.ID), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameStrings(names(got.Ingredients), []string{"bread"}) || len(got.IngredientIDs) != 1 {
+		t.Errorf("after delete: lines %q, ids %v", names(got.Ingredients), got.IngredientIDs)
+	}
+
+	standalone, err := b.Ingredients.Create(ctx, &model.NewIngredient{Name: "sugar", Type: "x", Quantity: "2 tbsp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, err := b.Ingredients.Update(ctx, &model.UpdateIngredient{ID: standalone.ID.Hex(), Name: "brown sugar", Type: "x", Quantity: "3 tbsp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "brown sugar" || updated.Measure == nil || updated.Measure.Amount == nil || *updated.Measure.Amount != 3 {
+		t.Errorf("updated = %q %+v", updated.Name, updated.Measure)
+	}
+}
+
+func testFoods(t *testing.T, b *Backend) {
+	alice := &model.User{ID: primitive.NewObjectID()}
+	ctx := auth.WithUser(context.Background(), alice)
+	category := "dairy"
+	food, err := b.Foods.Create(ctx, &model.NewFood{Name: "Egg", Synonyms: []string{"eggs"}, Category: &category})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if food.CreatedBy == nil || *food.CreatedBy != alice.ID {
+		t.Errorf("food created by %v, want alice", food.CreatedBy)
+	}
+	for _, name := range []string{"egg", "Eggs"} {
+		got, err := b.Foods.Get(ctx, name)
+		if err != nil || got.ID != food.ID {
+			t.Errorf("Get(%q) = %v, %v", name, got, err)
+		}
+	}
+	_, err = b.Foods.Create(ctx, &model.NewFood{Name: "eggs"})
+	wantCode(t, err, apperr.Conflict)
+
+	recipe, err := b.Recipes.Create(ctx, newRecipe("Quiche", "eggs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := recipe.Ingredients[0].FoodID; id == nil || *id != food.ID {
+		t.Errorf("line food = %v, want %s", id, food.ID.Hex())
+	}
+
+	updated, err := b.Foods.Update(ctx, &model.UpdateFood{ID: food.ID.Hex(), Name: "Hen egg", Synonyms: []string{"egg", "eggs"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Slug != "hen-egg" {
+		t.Errorf("slug = %q, want hen-egg", updated.Slug)
+	}
+	if updated.CreatedBy == nil || *updated.CreatedBy != alice.ID {
+		t.Errorf("update changed the author to %v", updated.CreatedBy)
+	}
+	found, err := b.Foods.ByIDs(ctx, []primitive.ObjectID{food.ID})
+	if err != nil || len(found) != 1 {
+		t.Errorf("ByIDs = %d foods, %v", len(found), err)
+	}
+}
+
+func testShoppingLists(t *testing.T, b *Backend) {
+	alice := &model.User{ID: primitive.NewObjectID()}
+	bob := &model.User{ID: primitive.NewObjectID()}
+	ctx := auth.WithUser(context.Background(), alice)
+	name := "Week"
+	item := &model.ShoppingItem{ID: primitive.NewObjectID(), Name: "flour", RecipeIDs: []primitive.ObjectID{}}
+	list, err := b.Lists.Create(ctx, &model.ShoppingList{Name: &name, Units: model.UnitSystemMetric, Recipes: []*model.ShoppingListRecipe{}, Items: []*model.ShoppingItem{item}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.ID.IsZero() {
+		t.Fatal("list saved without an ID")
+	}
+	if list.CreatedBy == nil || *list.CreatedBy != alice.ID {
+		t.Errorf("list created by %v, want alice", list.CreatedBy)
+	}
+	checked, err := b.Lists.Check(ctx, &model.CheckShoppingItem{ListID: list.ID.Hex(), ItemID: item.ID.Hex(), Checked: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checked.Items[0].Checked {
+		t.Error("item not checked")
+	}
+	_, err = b.Lists.Check(ctx, &model.CheckShoppingItem{ListID: list.ID.Hex(), ItemID: primitive.NewObjectID().Hex(), Checked: true})
+	wantCode(t, err, apperr.NotFound)
+
+	newer, err := b.Lists.Create(ctx, &model.ShoppingList{Units: model.UnitSystemUs, Recipes: []*model.ShoppingListRecipe{}, Items: []*model.ShoppingItem{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Lists.Create(auth.WithUser(context.Background(), bob), &model.ShoppingList{Units: model.UnitSystemUs, Recipes: []*model.ShoppingListRecipe{}, Items: []*model.ShoppingItem{}}); err != nil {
+		t.Fatal(err)
+	}
+	all, err := b.Lists.All(ctx, alice.ID, 12, 1)
+	if err != nil || len(all) != 2 || all[0].ID != newer.ID {
+		t.Errorf("All = %d lists, %v, want alice's two, the newest first", len(all), err)
+	}
+
+	if _, err := b.Lists.Delete(ctx, list.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	_, err = b.Lists.Get(ctx, list.ID.Hex())
+	wantCode(t, err, apperr.NotFound)
+}
+
+func testPantries(t *testing.T, b *Backend) {
+	alice := &model.User{ID: primitive.NewObjectID()}
+	ctx := auth.WithUser(context.Background(), alice)
+	pantry, err := b.Pantries.Create(ctx, &model.NewPantry{Name: "Home", Items: []*model.PantryItemInput{{Name: "rice", Quantity: "1 kg"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pantry.CreatedBy == nil || *pantry.CreatedBy != alice.ID {
+		t.Errorf("pantry created by %v, want alice", pantry.CreatedBy)
+	}
+	if all, err := b.Pantries.All(ctx, primitive.NewObjectID(), 12, 1); err != nil || len(all) != 0 {
+		t.Errorf("All for someone else = %d pantries, %v", len(all), err)
+	}
+	rice := pantry.Items[0]
+	if rice.FoodID == nil {
+		t.Error("item not linked to a food")
+	}
+	riceID := rice.ID.Hex()
+	updated, err := b.Pantries.Update(ctx, &model.UpdatePantry{ID: pantry.ID.Hex(), Name: "Cottage", Items: []*model.PantryItemInput{
+		{ID: &riceID, Name: "rice", Quantity: "500 g"},
+		{Name: "beans", Quantity: "1 can"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != "Cottage" || len(updated.Items) != 2 || updated.Items[0].ID != rice.ID {
+		t.Errorf("updated = %q with %d items", updated.Name, len(updated.Items))
+	}
+	if _, err := b.Pantries.Delete(ctx, pantry.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	_, err = b.Pantries.Get(ctx, pantry.ID.Hex())
+	wantCode(t, err, apperr.NotFound)
+}
+
+func testMealPlans(t *testing.T, b *Backend) {
+	alice := &model.User{ID: primitive.NewObjectID()}
+	ctx := auth.WithUser(context.Background(), alice)
+	plan, err := b.Plans.Create(ctx, &model.NewMealPlan{Name: "March"}, "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.CreatedBy == nil || *plan.CreatedBy != alice.ID {
+		t.Errorf("plan created by %v, want alice", plan.CreatedBy)
+	}
+	if all, err := b.Plans.All(ctx, alice.ID, 12, 1); err != nil || len(all) != 1 {
+		t.Errorf("All = %d plans, %v, want 1", len(all), err)
+	}
+	if plan.FeedToken != "first" {
+		t.Errorf("feed token = %q, want first", plan.FeedToken)
+	}
+	plan, err = b.Plans.ResetFeed(ctx, plan.ID.Hex(), "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := b.Plans.Get(ctx, plan.ID.Hex()); err != nil || got.FeedToken != "second" {
+		t.Errorf("feed token after reset = %v, %v, want second", got, err)
+	}
+	_, err = b.Plans.ResetFeed(ctx, primitive.NewObjectID().Hex(), "third")
+	wantCode(t, err, apperr.NotFound)
+	recipe := primitive.NewObjectID().Hex()
+	plan, err = b.Plans.Plan(ctx, &model.PlanMeal{PlanID: plan.ID.Hex(), RecipeID: recipe, Date: "2026-03-14", Slot: model.MealSlotDinner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Entries) != 1 {
+		t.Fatalf("plan has %d meals, want 1", len(plan.Entries))
+	}
+	entry := plan.Entries[0]
+	plan, err = b.Plans.Move(ctx, &model.MoveMeal{PlanID: plan.ID.Hex(), EntryID: entry.ID.Hex(), Date: "2026-03-15", Slot: model.MealSlotLunch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := plan.Entries[0]; e.Date != "2026-03-15" || e.Slot != model.MealSlotLunch {
+		t.Errorf("moved to %s %s", e.Date, e.Slot)
+	}
+	plan, err = b.Plans.Remove(ctx, plan.ID.Hex(), entry.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Entries) != 0 {
+		t.Errorf("plan has %d meals after removing the only one", len(plan.Entries))
+	}
+	_, err = b.Plans.Remove(ctx, plan.ID.Hex(), entry.ID.Hex())
+	wantCode(t, err, apperr.NotFound)
+	if _, err := b.Plans.Delete(ctx, plan.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+	_, err = b.Plans.Get(ctx, plan.ID.Hex())
+	wantCode(t, err, apperr.NotFound)
+}
+
+func testUsers(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	user, err := b.Users.Create(ctx, &model.User{Email: " Alice@Example.com", Name: "Alice", Role: model.RoleUser, PasswordHash: []byte("hash")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "alice@example.com" {
+		t.Errorf("email stored as %q", user.Email)
+	}
+	_, err = b.Users.Create(ctx, &model.User{Email: "ALICE@example.com", Name: "Other"})
+	wantCode(t, err, apperr.Conflict)
+
+	found, err := b.Users.ByEmail(ctx, "alice@EXAMPLE.com")
+	if err != nil || found.ID != user.ID || string(found.PasswordHash) != "hash" {
+		t.Errorf("ByEmail = %v, %v", found, err)
+	}
+	_, err = b.Users.ByEmail(ctx, "bob@example.com")
+	wantCode(t, err, apperr.NotFound)
+
+	admin, err := b.Users.SetRole(ctx, user.ID.Hex(), model.RoleAdmin)
+	if err != nil || admin.Role != model.RoleAdmin {
+		t.Errorf("SetRole = %v, %v", admin, err)
+	}
+	users, err := b.Users.ByIDs(ctx, []primitive.ObjectID{user.ID, primitive.NewObjectID()})
+	if err != nil || len(users) != 1 {
+		t.Errorf("ByIDs = %d users, %v", len(users), err)
+	}
+}
+
+func testAPIKeys(t *testing.T, b *Backend) {
+	ctx := context.Background()
+	key, err := b.Keys.Create(ctx, &model.APIKey{ID: primitive.NewObjectID(), Name: "partner", Scopes: []string{model.ScopeRecipesRead}, Hash: []byte("one")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := time.Now().Truncate(time.Millisecond)
+	for _, at := range []time.Time{first, first.Add(-time.Minute)} {
+		if err := b.Keys.Touch(ctx, key.ID, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := b.Keys.Get(ctx, key.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Requests != 2 || got.LastUsedAt == nil || !got.LastUsedAt.Equal(first) {
+		t.Errorf("requests %d, last used %v, want 2 at %v", got.Requests, got.LastUsedAt, first)
+	}
+
+	rotated, err := b.Keys.Rotate(ctx, key.ID.Hex(), []byte("two"), time.Now())
+	if err != nil || string(rotated.Hash) != "two" || rotated.RotatedAt == nil {
+		t.Errorf("Rotate = %v, %v", rotated, err)
+	}
+	revoked, err := b.Keys.Revoke(ctx, key.ID.Hex(), time.Now())
+	if err != nil || revoked.RevokedAt == nil {
+		t.Errorf("Revoke = %v, %v", revoked, err)
+	}
+	_, err = b.Keys.Revoke(ctx, primitive.NewObjectID().Hex(), time.Now())
+	wantCode(t, err, apperr.NotFound)
+
+	all, err := b.Keys.All(ctx, 12, 1)
+	if err != nil || len(all) != 1 {
+		t.Errorf("All = %d keys, %v", len(all), err)
+	}
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type APIKeyManager struct {
+	s *Store
+}
+
+func NewAPIKeyManager(s *Store) *APIKeyManager {
+	return &APIKeyManager{s: s}
+}
+
+func (km *APIKeyManager) Create(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	km.s.mu.Lock()
+	defer km.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, k := range km.s.keys {
+		if k.ID == key.ID {
+			return nil, &apperr.Error{Code: apperr.Conflict, Message: "key " + key.ID.Hex() + " already exists"}
+		}
+	}
+	created := *key
+	km.s.keys = append(km.s.keys, &created)
+	out := created
+	return &out, nil
+}
+
+func (km *APIKeyManager) Rotate(ctx context.Context, id string, hash []byte, at time.Time) (*model.APIKey, error) {
+	return km.update(ctx, id, func(k *model.APIKey) {
+		k.Hash, k.RotatedAt = hash, &at
+	})
+}
+
+func (km *APIKeyManager) Revoke(ctx context.Context, id string, at time.Time) (*model.APIKey, error) {
+	return km.update(ctx, id, func(k *model.APIKey) {
+		k.RevokedAt = &at
+	})
+}
+
+// update applies change to the stored key with the given ID. change must
+// replace the fields it sets rather than write through them, since copies
+// handed out by reads share them.
+func (km *APIKeyManager) update(ctx context.Context, id string, change func(k *model.APIKey)) (*model.APIKey, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	km.s.mu.Lock()
+	defer km.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, k := range km.s.keys {
+		if k.ID == oid {
+			change(k)
+			updated := *k
+			return &updated, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (km *APIKeyManager) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	km.s.mu.Lock()
+	defer km.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, k := range km.s.keys {
+		if k.ID == id {
+			k.Requests++
+			if k.LastUsedAt == nil || at.After(*k.LastUsedAt) {
+				k.LastUsedAt = &at
+			}
+		}
+	}
+	return nil
+}
+
+func (km *APIKeyManager) Get(ctx context.Context, id string) (*model.APIKey, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	km.s.mu.RLock()
+	defer km.s.mu.RUnlock()
+	for _, k := range km.s.keys {
+		if k.ID == oid {
+			found := *k
+			return &found, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (km *APIKeyManager) All(ctx context.Context, limit int, page int) ([]*model.APIKey, error) {
+	km.s.mu.RLock()
+	defer km.s.mu.RUnlock()
+
+	found := append([]*model.APIKey(nil), km.s.keys...)
+	sort.SliceStable(found, func(a, b int) bool { return found[a].ID.Hex() > found[b].ID.Hex() })
+	start, end, _ := paginate(len(found), limit, page)
+	keys := []*model.APIKey{}
+	for _, k := range found[start:end] {
+		key := *k
+		keys = append(keys, &key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/text"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// checkIDs rejects malformed IDs up front, as the MongoDB translation does
+// when it converts them to ObjectIDs.
+func checkIDs(ids map[string]*string) error {
+	for field, id := range ids {
+		if id == nil {
+			continue
+		}
+		if _, err := primitive.ObjectIDFromHex(*id); err != nil {
+			return apperr.Invalid([]string{"filter", field}, "invalid %s", field)
+		}
+	}
+	return nil
+}
+
+func checkRecipeFilter(f *model.RecipeFilter) error {
+	if f == nil {
+		return nil
+	}
+	for name, d := range map[string]*model.DurationFilter{"prepTime": f.PrepTime, "cookTime": f.CookTime, "totalTime": f.TotalTime} {
+		if _, err := d.Bounds([]string{"filter", name}); err != nil {
+			return err
+		}
+	}
+	return checkIDs(map[string]*string{"id": f.ID})
+}
+
+func checkIngredientFilter(f *model.IngredientFilter) error {
+	if f == nil {
+		return nil
+	}
+	return checkIDs(map[string]*string{"id": f.ID, "recipeID": f.RecipeID})
+}
+
+// matchRecipe evaluates f against r the way the MongoDB translation of the
+// same filter would.
+func (s *Store) matchRecipe(r *model.Recipe, f *model.RecipeFilter) bool {
+	if f == nil {
+		return true
+	}
+	if f.ID != nil && r.ID.Hex() != *f.ID {
+		return false
+	}
+	if !matchString(r.Name, f.Name) || !matchString(deref(r.Slug), f.Slug) || !matchTime(r.ID, f.CreatedAt) {
+		return false
+	}
+	if !matchDuration(r.PrepTime, f.PrepTime) || !matchDuration(r.CookTime, f.CookTime) || !matchDuration(r.TotalTime, f.TotalTime) {
+		return false
+	}
+	if f.HasIngredient != nil && !s.hasIngredient(r, text.Slugify(*f.HasIngredient)) {
+		return false
+	}
+	if f.FoodIDs != nil && !s.usesFood(r, f.FoodIDs) {
+		return false
+	}
+	return f.Raw == nil || match(r, f.Raw)
+}
+
+// hasIngredient reports whether a line of r is made of the food going by
+// key.
+func (s *Store) hasIngredient(r *model.Recipe, key string) bool {
+	food := s.food(key)
+	if food == nil {
+		return false
+	}
+	for _, i := range s.ingredients {
+		if i.RecipeID == r.ID && i.FoodID != nil && *i.FoodID == food.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// usesFood reports whether a line of r is made of one of foods.
+func (s *Store) usesFood(r *model.Recipe, foods []primitive.ObjectID) bool {
+	for _, i := range s.ingredients {
+		if i.RecipeID != r.ID || i.FoodID == nil {
+			continue
+		}
+		for _, id := range foods {
+			if *i.FoodID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchIngredient(i *model.Ingredient, f *model.IngredientFilter) bool {
+	if f == nil {
+		return true
+	}
+	if f.ID != nil && i.ID.Hex() != *f.ID {
+		return false
+	}
+	if f.RecipeID != nil && i.RecipeID.Hex() != *f.RecipeID {
+		return false
+	}
+	if !matchString(i.Name, f.Name) || !matchString(deref(i.Slug), f.Slug) || !matchString(i.Type, f.Type) {
+		return false
+	}
+	if !matchTime(i.ID, f.CreatedAt) {
+		return false
+	}
+	return f.Raw == nil || match(i, f.Raw)
+}
+
+func matchString(v string, f *model.StringFilter) bool {
+	if f == nil {
+		return true
+	}
+	if f.Eq != nil && v != *f.Eq {
+		return false
+	}
+	if f.In != nil {
+		found := false
+		for _, s := range f.In {
+			if s == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.Contains != nil && !strings.Contains(strings.ToLower(v), strings.ToLower(*f.Contains)) {
+		return false
+	}
+	return true
+}
+
+// matchTime compares whole seconds, the precision of ObjectID timestamps.
+func matchTime(id primitive.ObjectID, r *model.TimeRange) bool {
+	if r == nil {
+		return true
+	}
+	created := id.Timestamp().Unix()
+	if r.From != nil && created < r.From.Unix() {
+		return false
+	}
+	if r.To != nil && created >= r.To.Unix() {
+		return false
+	}
+	return true
+}
+
+// matchDuration expects f to have been checked by checkRecipeFilter.
+func matchDuration(seconds *int, f *model.DurationFilter) bool {
+	bounds, _ := f.Bounds(nil)
+	for _, b := range bounds {
+		if !b.Holds(seconds) {
+			return false
+		}
+	}
+	return true
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// sortRecipes orders recipes as the MongoDB orderStages do: by the field of
+// o, recipes without it last, ties in insertion order, which is _id order.
+func sortRecipes(recipes []*model.Recipe, o *model.RecipeOrder) {
+	if o == nil {
+		return
+	}
+	desc := o.Direction == model.OrderDirectionDesc
+	if o.Field == model.RecipeOrderFieldCreatedAt {
+		if desc {
+			sort.SliceStable(recipes, func(a, b int) bool { return recipes[a].ID.Hex() > recipes[b].ID.Hex() })
+		}
+		return
+	}
+	if o.Field == model.RecipeOrderFieldName {
+		sort.SliceStable(recipes, func(a, b int) bool {
+			if desc {
+				return recipes[a].Name > recipes[b].Name
+			}
+			return recipes[a].Name < recipes[b].Name
+		})
+		return
+	}
+
+	seconds := func(r *model.Recipe) *int {
+		switch o.Field {
+		case model.RecipeOrderFieldPrepTime:
+			return r.PrepTime
+		case model.RecipeOrderFieldCookTime:
+			return r.CookTime
+		}
+		return r.TotalTime
+	}
+	sort.SliceStable(recipes, func(a, b int) bool {
+		x, y := seconds(recipes[a]), seconds(recipes[b])
+		switch {
+		case x == nil || y == nil:
+			return x != nil && y == nil
+		case desc:
+			return *x > *y
+		}
+		return *x < *y
+	})
+}
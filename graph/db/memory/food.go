@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/text"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type FoodManager struct {
+	s *Store
+}
+
+func NewFoodManager(s *Store) *FoodManager {
+	return &FoodManager{s: s}
+}
+
+func (fm *FoodManager) Create(ctx context.Context, args *model.NewFood) (*model.Food, error) {
+	food := model.MakeFood(args.Name, args.Synonyms, args.Category, args.Density)
+	food.CreatedBy = auth.UserID(ctx)
+
+	fm.s.mu.Lock()
+	defer fm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := fm.s.checkKeys(food); err != nil {
+		return nil, err
+	}
+	fm.s.foods = append(fm.s.foods, food)
+	created := *food
+	return &created, nil
+}
+
+func (fm *FoodManager) Update(ctx context.Context, args *model.UpdateFood) (*model.Food, error) {
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
+	}
+	food := model.MakeFood(args.Name, args.Synonyms, args.Category, args.Density)
+	food.ID = id
+
+	fm.s.mu.Lock()
+	defer fm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for n, f := range fm.s.foods {
+		if f.ID != id {
+			continue
+		}
+		if err := fm.s.checkKeys(food); err != nil {
+			return nil, err
+		}
+		food.CreatedBy = f.CreatedBy
+		fm.s.foods[n] = food
+		updated := *food
+		return &updated, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (fm *FoodManager) Get(ctx context.Context, name string) (*model.Food, error) {
+	fm.s.mu.RLock()
+	defer fm.s.mu.RUnlock()
+
+	if f := fm.s.food(text.Slugify(name)); f != nil {
+		found := *f
+		return &found, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (fm *FoodManager) All(ctx context.Context, filter *model.FoodFilter, limit int, page int) ([]*model.Food, error) {
+	fm.s.mu.RLock()
+	defer fm.s.mu.RUnlock()
+
+	var found []*model.Food
+	for _, f := range fm.s.foods {
+		if matchFood(f, filter) {
+			found = append(found, f)
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool { return found[a].Slug < found[b].Slug })
+
+	start, end, _ := paginate(len(found), limit, page)
+	foods := []*model.Food{}
+	for _, f := range found[start:end] {
+		food := *f
+		foods = append(foods, &food)
+	}
+	return foods, nil
+}
+
+func (fm *FoodManager) ByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.Food, error) {
+	fm.s.mu.RLock()
+	defer fm.s.mu.RUnlock()
+
+	want := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	var foods []*model.Food
+	for _, f := range fm.s.foods {
+		if want[f.ID] {
+			food := *f
+			foods = append(foods, &food)
+		}
+	}
+	return foods, nil
+}
+
+func matchFood(f *model.Food, filter *model.FoodFilter) bool {
+	if filter == nil {
+		return true
+	}
+	return matchString(f.Name, filter.Name) && matchString(f.Slug, filter.Slug) && matchString(deref(f.Category), filter.Category)
+}
+
+// food returns the food going by key, or nil. The caller holds the lock.
+func (s *Store) food(key string) *model.Food {
+	for _, f := range s.foods {
+		for _, k := range f.Keys {
+			if k == key {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// checkKeys fails with a conflict when another food already goes by one of
+// the keys of food. The caller holds the lock.
+func (s *Store) checkKeys(food *model.Food) error {
+	for _, f := range s.foods {
+		if f.ID == food.ID {
+			continue
+		}
+		if err := food.Conflict(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// foodFor returns the ID of the food a line named name is made of, adding
+// it to the catalog when it is new, or nil for names without a slug. The
+// caller holds the write lock.
+func (s *Store) foodFor(name string) *primitive.ObjectID {
+	key := text.Slugify(name)
+	if key == "" {
+		return nil
+	}
+	f := s.food(key)
+	if f == nil {
+		f = model.MakeFood(name, nil, nil, nil)
+		s.foods = append(s.foods, f)
+	}
+	id := f.ID
+	return &id
+}
@@ -0,0 +1,232 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"github.com/ottolauncher/recipes/utils/text"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IngredientManager struct {
+	s *Store
+}
+
+func NewIngredientManager(s *Store) *IngredientManager {
+	return &IngredientManager{s: s}
+}
+
+func (im *IngredientManager) Bulk(ctx context.Context, args []*model.NewIngredient) ([]*model.Ingredient, error) {
+	im.s.mu.Lock()
+	defer im.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var ingredients []*model.Ingredient
+	by := auth.UserID(ctx)
+	for _, args := range args {
+		slug := text.Slugify(args.Name)
+		measure := quantity.Parse(args.Quantity)
+		ingredient := &model.Ingredient{
+			ID:        primitive.NewObjectID(),
+			Name:      args.Name,
+			Slug:      &slug,
+			Type:      args.Type,
+			Quantity:  args.Quantity,
+			Measure:   &measure,
+			FoodID:    im.s.foodFor(args.Name),
+			CreatedBy: by,
+			UpdatedBy: by,
+		}
+		im.s.ingredients = append(im.s.ingredients, ingredient)
+		created := *ingredient
+		ingredients = append(ingredients, &created)
+	}
+	return ingredients, nil
+}
+
+func (im *IngredientManager) Create(ctx context.Context, args *model.NewIngredient) (*model.Ingredient, error) {
+	ingredients, err := im.Bulk(ctx, []*model.NewIngredient{args})
+	if err != nil {
+		return nil, err
+	}
+	return ingredients[0], nil
+}
+
+func (im *IngredientManager) Update(ctx context.Context, args *model.UpdateIngredient) (*model.Ingredient, error) {
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
+	}
+	slug := text.Slugify(args.Name)
+	measure := quantity.Parse(args.Quantity)
+
+	im.s.mu.Lock()
+	defer im.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, i := range im.s.ingredients {
+		if i.ID == id {
+			i.Name = args.Name
+			i.Slug = &slug
+			i.Type = args.Type
+			i.Quantity = args.Quantity
+			i.Measure = &measure
+			i.FoodID = im.s.foodFor(args.Name)
+			i.UpdatedBy = auth.UserID(ctx)
+			updated := *i
+			return &updated, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (im *IngredientManager) Delete(ctx context.Context, filter *model.IngredientFilter) (*model.Ingredient, error) {
+	if err := checkIngredientFilter(filter); err != nil {
+		return nil, err
+	}
+	im.s.mu.Lock()
+	defer im.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for n, i := range im.s.ingredients {
+		if matchIngredient(i, filter) {
+			im.s.ingredients = append(im.s.ingredients[:n], im.s.ingredients[n+1:]...)
+			im.s.unlink(i)
+			return i, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (im *IngredientManager) Get(ctx context.Context, filter *model.IngredientFilter, fields []string) (*model.Ingredient, error) {
+	if err := checkIngredientFilter(filter); err != nil {
+		return nil, err
+	}
+	im.s.mu.RLock()
+	defer im.s.mu.RUnlock()
+
+	for _, i := range im.s.ingredients {
+		if matchIngredient(i, filter) {
+			ingredient := *i
+			return &ingredient, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (im *IngredientManager) All(ctx context.Context, filter *model.IngredientFilter, fields []string, limit int, page int) ([]*model.Ingredient, error) {
+	if err := checkIngredientFilter(filter); err != nil {
+		return nil, err
+	}
+	im.s.mu.RLock()
+	defer im.s.mu.RUnlock()
+
+	var found []*model.Ingredient
+	for _, i := range im.s.ingredients {
+		if matchIngredient(i, filter) {
+			found = append(found, i)
+		}
+	}
+	return im.page(found, limit, page), nil
+}
+
+func (im *IngredientManager) Search(ctx context.Context, query string, limit int, page int) ([]*model.Ingredient, error) {
+	im.s.mu.RLock()
+	defer im.s.mu.RUnlock()
+
+	var found []*model.Ingredient
+	for _, i := range im.s.ingredients {
+		if contains(query, i.Name, i.Type) {
+			found = append(found, i)
+		}
+	}
+	ingredients := im.page(found, limit, page)
+	if len(ingredients) == 0 {
+		return ingredients, mongo.ErrNoDocuments
+	}
+	return ingredients, nil
+}
+
+func (im *IngredientManager) page(found []*model.Ingredient, limit int, page int) []*model.Ingredient {
+	start, end, data := paginate(len(found), limit, page)
+
+	var ingredients []*model.Ingredient
+	for _, i := range found[start:end] {
+		ingredient := *i
+		ingredient.Pagination = data
+		ingredients = append(ingredients, &ingredient)
+	}
+	return ingredients
+}
+
+func (im *IngredientManager) AllAfter(ctx context.Context, filter *model.IngredientFilter, fields []string, first int, after *primitive.ObjectID) ([]*model.Ingredient, bool, error) {
+	if err := checkIngredientFilter(filter); err != nil {
+		return nil, false, err
+	}
+	im.s.mu.RLock()
+	defer im.s.mu.RUnlock()
+
+	var found []*model.Ingredient
+	for _, i := range im.s.ingredients {
+		if matchIngredient(i, filter) {
+			found = append(found, i)
+		}
+	}
+	ingredients, more := im.after(found, first, after)
+	return ingredients, more, nil
+}
+
+func (im *IngredientManager) SearchAfter(ctx context.Context, query string, first int, after *primitive.ObjectID) ([]*model.Ingredient, bool, error) {
+	im.s.mu.RLock()
+	defer im.s.mu.RUnlock()
+
+	var found []*model.Ingredient
+	for _, i := range im.s.ingredients {
+		if contains(query, i.Name, i.Type) {
+			found = append(found, i)
+		}
+	}
+	ingredients, more := im.after(found, first, after)
+	return ingredients, more, nil
+}
+
+func (im *IngredientManager) after(found []*model.Ingredient, first int, after *primitive.ObjectID) ([]*model.Ingredient, bool) {
+	ids := make([]primitive.ObjectID, len(found))
+	for n, i := range found {
+		ids[n] = i.ID
+	}
+	selected, more := seek(ids, first, after)
+
+	var ingredients []*model.Ingredient
+	for _, n := range selected {
+		ingredient := *found[n]
+		ingredients = append(ingredients, &ingredient)
+	}
+	return ingredients, more
+}
+
+func (im *IngredientManager) ByRecipes(ctx context.Context, recipeIDs []primitive.ObjectID) ([]*model.Ingredient, error) {
+	im.s.mu.RLock()
+	defer im.s.mu.RUnlock()
+
+	want := make(map[primitive.ObjectID]bool, len(recipeIDs))
+	for _, id := range recipeIDs {
+		want[id] = true
+	}
+	var ingredients []*model.Ingredient
+	for _, i := range im.s.ingredients {
+		if want[i.RecipeID] {
+			ingredient := *i
+			ingredients = append(ingredients, &ingredient)
+		}
+	}
+	return ingredients, nil
+}
@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MealPlanManager struct {
+	s *Store
+}
+
+func NewMealPlanManager(s *Store) *MealPlanManager {
+	return &MealPlanManager{s: s}
+}
+
+func (mm *MealPlanManager) Create(ctx context.Context, args *model.NewMealPlan, feedToken string) (*model.MealPlan, error) {
+	mm.s.mu.Lock()
+	defer mm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	plan := &model.MealPlan{ID: primitive.NewObjectID(), Name: args.Name, Entries: []*model.MealPlanEntry{}, FeedToken: feedToken, CreatedBy: auth.UserID(ctx)}
+	mm.s.plans = append(mm.s.plans, plan)
+	return copyPlan(plan), nil
+}
+
+func (mm *MealPlanManager) Plan(ctx context.Context, args *model.PlanMeal) (*model.MealPlan, error) {
+	id, err := primitive.ObjectIDFromHex(args.PlanID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "planID"}, "invalid id %q", args.PlanID)
+	}
+	recipe, err := primitive.ObjectIDFromHex(args.RecipeID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "recipeID"}, "invalid id %q", args.RecipeID)
+	}
+
+	mm.s.mu.Lock()
+	defer mm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, p := range mm.s.plans {
+		if p.ID == id {
+			entry := &model.MealPlanEntry{ID: primitive.NewObjectID(), RecipeID: recipe, Date: args.Date, Slot: args.Slot, Servings: args.Servings}
+			p.Entries = append(p.Entries, entry)
+			return copyPlan(p), nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (mm *MealPlanManager) Move(ctx context.Context, args *model.MoveMeal) (*model.MealPlan, error) {
+	id, err := primitive.ObjectIDFromHex(args.PlanID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "planID"}, "invalid id %q", args.PlanID)
+	}
+	entry, err := primitive.ObjectIDFromHex(args.EntryID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "entryID"}, "invalid id %q", args.EntryID)
+	}
+
+	mm.s.mu.Lock()
+	defer mm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, p := range mm.s.plans {
+		if p.ID != id {
+			continue
+		}
+		for _, e := range p.Entries {
+			if e.ID == entry {
+				e.Date, e.Slot = args.Date, args.Slot
+				return copyPlan(p), nil
+			}
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (mm *MealPlanManager) Remove(ctx context.Context, planID string, entryID string) (*model.MealPlan, error) {
+	id, err := primitive.ObjectIDFromHex(planID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"planID"}, "invalid id %q", planID)
+	}
+	entry, err := primitive.ObjectIDFromHex(entryID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"entryID"}, "invalid id %q", entryID)
+	}
+
+	mm.s.mu.Lock()
+	defer mm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, p := range mm.s.plans {
+		if p.ID != id {
+			continue
+		}
+		for n, e := range p.Entries {
+			if e.ID == entry {
+				p.Entries = append(p.Entries[:n:n], p.Entries[n+1:]...)
+				return copyPlan(p), nil
+			}
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (mm *MealPlanManager) Delete(ctx context.Context, id string) (*model.MealPlan, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	mm.s.mu.Lock()
+	defer mm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for n, p := range mm.s.plans {
+		if p.ID == oid {
+			mm.s.plans = append(mm.s.plans[:n:n], mm.s.plans[n+1:]...)
+			return p, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (mm *MealPlanManager) ResetFeed(ctx context.Context, id string, feedToken string) (*model.MealPlan, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"planID"}, "invalid id %q", id)
+	}
+
+	mm.s.mu.Lock()
+	defer mm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, p := range mm.s.plans {
+		if p.ID == oid {
+			p.FeedToken = feedToken
+			return copyPlan(p), nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (mm *MealPlanManager) Get(ctx context.Context, id string) (*model.MealPlan, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	mm.s.mu.RLock()
+	defer mm.s.mu.RUnlock()
+	for _, p := range mm.s.plans {
+		if p.ID == oid {
+			return copyPlan(p), nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (mm *MealPlanManager) All(ctx context.Context, owner primitive.ObjectID, limit int, page int) ([]*model.MealPlan, error) {
+	mm.s.mu.RLock()
+	defer mm.s.mu.RUnlock()
+
+	var found []*model.MealPlan
+	for _, p := range mm.s.plans {
+		if p.CreatedBy != nil && *p.CreatedBy == owner {
+			found = append(found, p)
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool { return found[a].ID.Hex() > found[b].ID.Hex() })
+	start, end, _ := paginate(len(found), limit, page)
+	plans := []*model.MealPlan{}
+	for _, p := range found[start:end] {
+		plans = append(plans, copyPlan(p))
+	}
+	return plans, nil
+}
+
+// copyPlan copies p deep enough that moving a meal of the copy leaves p
+// alone.
+func copyPlan(p *model.MealPlan) *model.MealPlan {
+	out := *p
+	out.Entries = make([]*model.MealPlanEntry, len(p.Entries))
+	for n, e := range p.Entries {
+		entry := *e
+		out.Entries[n] = &entry
+	}
+	return &out
+}
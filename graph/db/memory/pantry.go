@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PantryManager struct {
+	s *Store
+}
+
+func NewPantryManager(s *Store) *PantryManager {
+	return &PantryManager{s: s}
+}
+
+func (pm *PantryManager) Create(ctx context.Context, args *model.NewPantry) (*model.Pantry, error) {
+	pm.s.mu.Lock()
+	defer pm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pantry := &model.Pantry{
+		ID:        primitive.NewObjectID(),
+		Name:      args.Name,
+		Items:     model.NewPantryItems(args.Items, nil),
+		CreatedBy: auth.UserID(ctx),
+	}
+	pm.link(pantry.Items)
+	pm.s.pantries = append(pm.s.pantries, pantry)
+	created := *pantry
+	return &created, nil
+}
+
+func (pm *PantryManager) Update(ctx context.Context, args *model.UpdatePantry) (*model.Pantry, error) {
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
+	}
+
+	pm.s.mu.Lock()
+	defer pm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, p := range pm.s.pantries {
+		if p.ID != id {
+			continue
+		}
+		// copies handed out by reads share the items, so they are replaced
+		// rather than changed
+		items := model.NewPantryItems(args.Items, p.Items)
+		pm.link(items)
+		p.Name = args.Name
+		p.Items = items
+		updated := *p
+		return &updated, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (pm *PantryManager) Delete(ctx context.Context, id string) (*model.Pantry, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	pm.s.mu.Lock()
+	defer pm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for n, p := range pm.s.pantries {
+		if p.ID == oid {
+			pm.s.pantries = append(pm.s.pantries[:n:n], pm.s.pantries[n+1:]...)
+			return p, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (pm *PantryManager) Get(ctx context.Context, id string) (*model.Pantry, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	pm.s.mu.RLock()
+	defer pm.s.mu.RUnlock()
+	for _, p := range pm.s.pantries {
+		if p.ID == oid {
+			found := *p
+			return &found, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (pm *PantryManager) All(ctx context.Context, owner primitive.ObjectID, limit int, page int) ([]*model.Pantry, error) {
+	pm.s.mu.RLock()
+	defer pm.s.mu.RUnlock()
+
+	var found []*model.Pantry
+	for _, p := range pm.s.pantries {
+		if p.CreatedBy != nil && *p.CreatedBy == owner {
+			found = append(found, p)
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool { return found[a].Name < found[b].Name })
+	start, end, _ := paginate(len(found), limit, page)
+	pantries := []*model.Pantry{}
+	for _, p := range found[start:end] {
+		pantry := *p
+		pantries = append(pantries, &pantry)
+	}
+	return pantries, nil
+}
+
+// link points the items to their foods. The caller holds the write lock.
+func (pm *PantryManager) link(items []*model.PantryItem) {
+	for _, i := range items {
+		i.FoodID = pm.s.foodFor(i.Name)
+	}
+}
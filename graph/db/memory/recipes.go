@@ -0,0 +1,388 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"github.com/ottolauncher/recipes/utils/text"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RecipeManager struct {
+	s *Store
+}
+
+func NewRecipeManager(s *Store) *RecipeManager {
+	return &RecipeManager{s: s}
+}
+
+// Recipes are stored the way the MongoDB implementation stores them: the
+// ingredient lines are separate documents and the recipe only keeps their
+// ordered IDs.
+
+func (tm *RecipeManager) Bulk(ctx context.Context, args []*model.NewRecipe) ([]*model.Recipe, error) {
+	tm.s.mu.Lock()
+	defer tm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var recipes []*model.Recipe
+	by := auth.UserID(ctx)
+	for _, v := range args {
+		recipes = append(recipes, tm.insert(v, by))
+	}
+	return recipes, nil
+}
+
+func (tm *RecipeManager) Create(ctx context.Context, args *model.NewRecipe) (*model.Recipe, error) {
+	tm.s.mu.Lock()
+	defer tm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return tm.insert(args, auth.UserID(ctx)), nil
+}
+
+// insert stores args as a recipe and its ingredient lines written by the
+// user with the ID by and returns the joined recipe. The caller holds the
+// write lock.
+func (tm *RecipeManager) insert(args *model.NewRecipe, by *primitive.ObjectID) *model.Recipe {
+	slug := text.Slugify(args.Name)
+	originalURL := args.OriginalURL
+	recipe := &model.Recipe{
+		ID:            primitive.NewObjectID(),
+		Name:          args.Name,
+		Slug:          &slug,
+		ImageURL:      args.ImageURL,
+		OriginalURL:   &originalURL,
+		Servings:      args.Servings,
+		Yield:         args.Yield,
+		IngredientIDs: []primitive.ObjectID{},
+		CreatedBy:     by,
+		UpdatedBy:     by,
+	}
+	recipe.SetTimers(model.NewTimers(args.Timers, args.Timings), model.Untimed(args.Timers))
+	recipe.Groups = args.GroupNames()
+	for _, line := range args.Lines() {
+		i := line.Ingredient
+		ingredient := newIngredient(primitive.NewObjectID(), recipe.ID, i.Name, i.Type, i.Quantity)
+		ingredient.Group = line.Group
+		ingredient.FoodID = tm.s.foodFor(i.Name)
+		ingredient.CreatedBy, ingredient.UpdatedBy = by, by
+		tm.s.ingredients = append(tm.s.ingredients, ingredient)
+		recipe.IngredientIDs = append(recipe.IngredientIDs, ingredient.ID)
+	}
+	recipe.SetSteps(model.NewSteps(args.Steps, args.Instructions, recipe.IngredientIDs))
+	tm.s.recipes = append(tm.s.recipes, recipe)
+	return tm.join(recipe)
+}
+
+func (tm *RecipeManager) Update(ctx context.Context, args *model.UpdateRecipe) (*model.Recipe, error) {
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
+	}
+	slug := text.Slugify(args.Name)
+	originalURL := args.OriginalURL
+	by := auth.UserID(ctx)
+
+	tm.s.mu.Lock()
+	defer tm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, r := range tm.s.recipes {
+		if r.ID != id {
+			continue
+		}
+
+		keep := make(map[primitive.ObjectID]*model.Ingredient)
+		var rest []*model.Ingredient
+		for _, i := range tm.s.ingredients {
+			if i.RecipeID == id {
+				keep[i.ID] = i
+			} else {
+				rest = append(rest, i)
+			}
+		}
+
+		r.IngredientIDs = []primitive.ObjectID{}
+		for _, line := range args.Lines() {
+			i := line.Ingredient
+			createdBy := by
+			iid, err := primitive.ObjectIDFromHex(i.ID)
+			if kept, ok := keep[iid]; err == nil && ok {
+				createdBy = kept.CreatedBy
+			} else {
+				iid = primitive.NewObjectID()
+			}
+			delete(keep, iid)
+			ingredient := newIngredient(iid, id, i.Name, i.Type, i.Quantity)
+			ingredient.Group = line.Group
+			ingredient.FoodID = tm.s.foodFor(i.Name)
+			ingredient.CreatedBy, ingredient.UpdatedBy = createdBy, by
+			rest = append(rest, ingredient)
+			r.IngredientIDs = append(r.IngredientIDs, iid)
+		}
+		r.Groups = args.GroupNames()
+		tm.s.ingredients = rest
+
+		r.Name = args.Name
+		r.Slug = &slug
+		r.SetTimers(model.NewTimers(args.Timers, args.Timings), model.Untimed(args.Timers))
+		r.SetSteps(model.NewSteps(args.Steps, args.Instructions, r.IngredientIDs))
+		r.ImageURL = args.ImageURL
+		r.OriginalURL = &originalURL
+		r.Servings = args.Servings
+		r.Yield = args.Yield
+		r.UpdatedBy = by
+		return tm.join(r), nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (tm *RecipeManager) Delete(ctx context.Context, filter *model.RecipeFilter) (*model.Recipe, error) {
+	if err := checkRecipeFilter(filter); err != nil {
+		return nil, err
+	}
+	tm.s.mu.Lock()
+	defer tm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for n, r := range tm.s.recipes {
+		if tm.s.matchRecipe(r, filter) {
+			deleted := tm.join(r)
+			tm.s.recipes = append(tm.s.recipes[:n], tm.s.recipes[n+1:]...)
+
+			var rest []*model.Ingredient
+			for _, i := range tm.s.ingredients {
+				if i.RecipeID != r.ID {
+					rest = append(rest, i)
+				}
+			}
+			tm.s.ingredients = rest
+			return deleted, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (tm *RecipeManager) Moderate(ctx context.Context, args *model.ModerateRecipe) (*model.Recipe, error) {
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
+	}
+	tm.s.mu.Lock()
+	defer tm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, r := range tm.s.recipes {
+		if r.ID == id {
+			r.Locked, r.ModerationNote = args.Locked, args.Note
+			recipe := *r
+			return &recipe, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (tm *RecipeManager) Get(ctx context.Context, filter *model.RecipeFilter, fields []string) (*model.Recipe, error) {
+	if err := checkRecipeFilter(filter); err != nil {
+		return nil, err
+	}
+	tm.s.mu.RLock()
+	defer tm.s.mu.RUnlock()
+
+	for _, r := range tm.s.recipes {
+		if tm.s.matchRecipe(r, filter) {
+			return tm.read(r, fields), nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (tm *RecipeManager) All(ctx context.Context, filter *model.RecipeFilter, order *model.RecipeOrder, fields []string, limit int, page int) ([]*model.Recipe, error) {
+	if err := checkRecipeFilter(filter); err != nil {
+		return nil, err
+	}
+	tm.s.mu.RLock()
+	defer tm.s.mu.RUnlock()
+
+	var found []*model.Recipe
+	for _, r := range tm.s.recipes {
+		if tm.s.matchRecipe(r, filter) {
+			found = append(found, r)
+		}
+	}
+	sortRecipes(found, order)
+	recipes := tm.page(found, fields, limit, page)
+	if len(recipes) == 0 {
+		return recipes, mongo.ErrNoDocuments
+	}
+	return recipes, nil
+}
+
+func (tm *RecipeManager) Search(ctx context.Context, query string, limit int, page int) ([]*model.Recipe, error) {
+	tm.s.mu.RLock()
+	defer tm.s.mu.RUnlock()
+
+	var found []*model.Recipe
+	for _, r := range tm.s.recipes {
+		if contains(query, r.Name, stepTexts(r)...) {
+			found = append(found, r)
+		}
+	}
+	recipes := tm.page(found, nil, limit, page)
+	if len(recipes) == 0 {
+		return recipes, mongo.ErrNoDocuments
+	}
+	return recipes, nil
+}
+
+// page copies one page of recipes.
+func (tm *RecipeManager) page(found []*model.Recipe, fields []string, limit int, page int) []*model.Recipe {
+	start, end, data := paginate(len(found), limit, page)
+
+	var recipes []*model.Recipe
+	for _, r := range found[start:end] {
+		recipe := tm.read(r, fields)
+		recipe.Pagination = data
+		recipes = append(recipes, recipe)
+	}
+	return recipes
+}
+
+// read copies r, joining its ingredients only when fields select them, as
+// the MongoDB implementation does. Otherwise Ingredients stays nil and the
+// GraphQL layer loads them when needed.
+func (tm *RecipeManager) read(r *model.Recipe, fields []string) *model.Recipe {
+	if selected(fields, "ingredients") {
+		return tm.join(r)
+	}
+	recipe := *r
+	return &recipe
+}
+
+// join copies r with the ingredient documents that reference it, the way
+// the $lookup stage does in the MongoDB implementation.
+func (tm *RecipeManager) join(r *model.Recipe) *model.Recipe {
+	recipe := *r
+	recipe.Ingredients = []*model.Ingredient{}
+	for _, i := range tm.s.ingredients {
+		if i.RecipeID == r.ID {
+			ingredient := *i
+			recipe.Ingredients = append(recipe.Ingredients, &ingredient)
+		}
+	}
+	recipe.Ingredients = model.OrderIngredients(r.IngredientIDs, recipe.Ingredients)
+	return &recipe
+}
+
+func newIngredient(id, recipeID primitive.ObjectID, name, kind, qty string) *model.Ingredient {
+	slug := text.Slugify(name)
+	measure := quantity.Parse(qty)
+	return &model.Ingredient{
+		ID:       id,
+		Name:     name,
+		Slug:     &slug,
+		Type:     kind,
+		Quantity: qty,
+		Measure:  &measure,
+		RecipeID: recipeID,
+	}
+}
+
+// stepTexts are what search looks through besides the name, as the text
+// index does in MongoDB.
+func stepTexts(r *model.Recipe) []string {
+	var texts []string
+	for _, s := range r.Structured() {
+		texts = append(texts, s.Text)
+	}
+	return texts
+}
+
+// contains mimics a $text search: any term of query found in one of the
+// indexed strings is a hit.
+func contains(query string, field string, fields ...string) bool {
+	fields = append([]string{field}, fields...)
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		for _, f := range fields {
+			if strings.Contains(strings.ToLower(f), term) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (tm *RecipeManager) AllAfter(ctx context.Context, filter *model.RecipeFilter, fields []string, first int, after *primitive.ObjectID) ([]*model.Recipe, bool, error) {
+	if err := checkRecipeFilter(filter); err != nil {
+		return nil, false, err
+	}
+	tm.s.mu.RLock()
+	defer tm.s.mu.RUnlock()
+
+	var found []*model.Recipe
+	for _, r := range tm.s.recipes {
+		if tm.s.matchRecipe(r, filter) {
+			found = append(found, r)
+		}
+	}
+	recipes, more := tm.after(found, fields, first, after)
+	return recipes, more, nil
+}
+
+func (tm *RecipeManager) SearchAfter(ctx context.Context, query string, first int, after *primitive.ObjectID) ([]*model.Recipe, bool, error) {
+	tm.s.mu.RLock()
+	defer tm.s.mu.RUnlock()
+
+	var found []*model.Recipe
+	for _, r := range tm.s.recipes {
+		if contains(query, r.Name, stepTexts(r)...) {
+			found = append(found, r)
+		}
+	}
+	recipes, more := tm.after(found, nil, first, after)
+	return recipes, more, nil
+}
+
+func (tm *RecipeManager) after(found []*model.Recipe, fields []string, first int, after *primitive.ObjectID) ([]*model.Recipe, bool) {
+	ids := make([]primitive.ObjectID, len(found))
+	for n, r := range found {
+		ids[n] = r.ID
+	}
+	selected, more := seek(ids, first, after)
+
+	var recipes []*model.Recipe
+	for _, n := range selected {
+		recipes = append(recipes, tm.read(found[n], fields))
+	}
+	return recipes, more
+}
+
+func (tm *RecipeManager) ByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.Recipe, error) {
+	tm.s.mu.RLock()
+	defer tm.s.mu.RUnlock()
+
+	want := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	var recipes []*model.Recipe
+	for _, r := range tm.s.recipes {
+		if want[r.ID] {
+			recipe := *r
+			recipes = append(recipes, &recipe)
+		}
+	}
+	return recipes, nil
+}
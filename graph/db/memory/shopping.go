@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ShoppingListManager struct {
+	s *Store
+}
+
+func NewShoppingListManager(s *Store) *ShoppingListManager {
+	return &ShoppingListManager{s: s}
+}
+
+func (sm *ShoppingListManager) Create(ctx context.Context, list *model.ShoppingList) (*model.ShoppingList, error) {
+	sm.s.mu.Lock()
+	defer sm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	created := copyList(list)
+	created.ID = primitive.NewObjectID()
+	created.CreatedBy = auth.UserID(ctx)
+	sm.s.lists = append(sm.s.lists, created)
+	return copyList(created), nil
+}
+
+func (sm *ShoppingListManager) Check(ctx context.Context, args *model.CheckShoppingItem) (*model.ShoppingList, error) {
+	id, err := primitive.ObjectIDFromHex(args.ListID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "listID"}, "invalid id %q", args.ListID)
+	}
+	item, err := primitive.ObjectIDFromHex(args.ItemID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "itemID"}, "invalid id %q", args.ItemID)
+	}
+
+	sm.s.mu.Lock()
+	defer sm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, l := range sm.s.lists {
+		if l.ID != id {
+			continue
+		}
+		for _, i := range l.Items {
+			if i.ID == item {
+				i.Checked = args.Checked
+				return copyList(l), nil
+			}
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (sm *ShoppingListManager) Delete(ctx context.Context, id string) (*model.ShoppingList, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	sm.s.mu.Lock()
+	defer sm.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for n, l := range sm.s.lists {
+		if l.ID == oid {
+			sm.s.lists = append(sm.s.lists[:n:n], sm.s.lists[n+1:]...)
+			return l, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (sm *ShoppingListManager) Get(ctx context.Context, id string) (*model.ShoppingList, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	sm.s.mu.RLock()
+	defer sm.s.mu.RUnlock()
+	for _, l := range sm.s.lists {
+		if l.ID == oid {
+			return copyList(l), nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (sm *ShoppingListManager) All(ctx context.Context, owner primitive.ObjectID, limit int, page int) ([]*model.ShoppingList, error) {
+	sm.s.mu.RLock()
+	defer sm.s.mu.RUnlock()
+
+	var found []*model.ShoppingList
+	for _, l := range sm.s.lists {
+		if l.CreatedBy != nil && *l.CreatedBy == owner {
+			found = append(found, l)
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool { return found[a].ID.Hex() > found[b].ID.Hex() })
+	start, end, _ := paginate(len(found), limit, page)
+	lists := []*model.ShoppingList{}
+	for _, l := range found[start:end] {
+		lists = append(lists, copyList(l))
+	}
+	return lists, nil
+}
+
+// copyList copies l deep enough that checking an item of the copy leaves l
+// alone.
+func copyList(l *model.ShoppingList) *model.ShoppingList {
+	out := *l
+	out.Items = make([]*model.ShoppingItem, len(l.Items))
+	for n, i := range l.Items {
+		item := *i
+		out.Items[n] = &item
+	}
+	return &out
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Store is an in-process stand-in for the recipedb database. It keeps every
+// collection in memory so the API can run without a MongoDB server.
+//
+// Writes hold the lock for the whole operation and check the caller's
+// context before they change anything, so a write spanning recipes and
+// ingredients is applied entirely or not at all, as a MongoDB transaction
+// would be.
+type Store struct {
+	mu          sync.RWMutex
+	recipes     []*model.Recipe
+	ingredients []*model.Ingredient
+	foods       []*model.Food
+	lists       []*model.ShoppingList
+	pantries    []*model.Pantry
+	plans       []*model.MealPlan
+	users       []*model.User
+	keys        []*model.APIKey
+}
+
+func NewStore() *Store {
+	return &Store{}
+}
+
+// unlink drops a deleted ingredient from the ordered IDs of its recipe. The
+// caller holds the write lock.
+func (s *Store) unlink(i *model.Ingredient) {
+	for _, r := range s.recipes {
+		if r.ID != i.RecipeID {
+			continue
+		}
+		r.IngredientIDs = without(r.IngredientIDs, i.ID)
+		// copies of r handed out by reads share the steps, so they are
+		// replaced rather than changed
+		steps := make([]*model.Step, len(r.Instructions))
+		for n, s := range r.Instructions {
+			step := *s
+			step.IngredientIDs = without(s.IngredientIDs, i.ID)
+			steps[n] = &step
+		}
+		if r.Instructions != nil {
+			r.Instructions = steps
+		}
+	}
+}
+
+// without returns ids less id, in a new array if id was among them.
+func without(ids []primitive.ObjectID, id primitive.ObjectID) []primitive.ObjectID {
+	for n, v := range ids {
+		if v == id {
+			return append(ids[:n:n], ids[n+1:]...)
+		}
+	}
+	return ids
+}
+
+// selected reports whether name is among the GraphQL fields a read asked
+// for; nil fields ask for everything. Ingredient reads copy whole documents
+// whatever the fields, since there is nothing to save in memory.
+func selected(fields []string, name string) bool {
+	if fields == nil {
+		return true
+	}
+	for _, f := range fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether v satisfies an equality filter expressed with the
+// same field names the documents use in MongoDB.
+func match(v interface{}, filter map[string]interface{}) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return false
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return false
+	}
+	for k, want := range filter {
+		got, ok := doc[k]
+		if !ok || fmt.Sprint(got) != fmt.Sprint(want) {
+			return false
+		}
+	}
+	return true
+}
+
+// paginate returns the bounds of the requested page together with the same
+// page metadata the MongoDB backend reports for it.
+func paginate(total, limit, page int) (int, int, model.Page) {
+	if limit <= 0 {
+		limit = 12
+	}
+	if page <= 0 {
+		page = 1
+	}
+	totalPage := int(math.Ceil(float64(total) / float64(limit)))
+	prev, next := page, page
+	if page > 1 {
+		prev = page - 1
+	}
+	if page < totalPage {
+		next = page + 1
+	}
+
+	start := (page - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+
+	data := model.Page{
+		Total:     total,
+		Page:      page,
+		PerPage:   limit,
+		Prev:      prev,
+		Next:      next,
+		TotalPage: totalPage,
+	}
+	return start, end, data
+}
+
+// seek mirrors the keyset pagination of the MongoDB backend: ids are sorted,
+// those up to and including after are skipped, and at most first are kept.
+// It returns the indexes of the selected ids and whether more follow.
+func seek(ids []primitive.ObjectID, first int, after *primitive.ObjectID) ([]int, bool) {
+	order := make([]int, len(ids))
+	for n := range order {
+		order[n] = n
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return bytes.Compare(ids[order[a]][:], ids[order[b]][:]) < 0
+	})
+
+	var selected []int
+	for _, n := range order {
+		if after != nil && bytes.Compare(ids[n][:], after[:]) <= 0 {
+			continue
+		}
+		if len(selected) == first {
+			return selected, true
+		}
+		selected = append(selected, n)
+	}
+	return selected, false
+}
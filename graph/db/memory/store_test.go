@@ -0,0 +1,23 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/ottolauncher/recipes/graph/db/dbtest"
+)
+
+func TestStore(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) *dbtest.Backend {
+		s := NewStore()
+		return &dbtest.Backend{
+			Recipes:     NewRecipeManager(s),
+			Ingredients: NewIngredientManager(s),
+			Foods:       NewFoodManager(s),
+			Lists:       NewShoppingListManager(s),
+			Pantries:    NewPantryManager(s),
+			Plans:       NewMealPlanManager(s),
+			Users:       NewUserManager(s),
+			Keys:        NewAPIKeyManager(s),
+		}
+	})
+}
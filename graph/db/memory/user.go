@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UserManager struct {
+	s *Store
+}
+
+func NewUserManager(s *Store) *UserManager {
+	return &UserManager{s: s}
+}
+
+func (um *UserManager) Create(ctx context.Context, user *model.User) (*model.User, error) {
+	created := *user
+	created.ID = primitive.NewObjectID()
+	created.Email = model.NormalizeEmail(user.Email)
+
+	um.s.mu.Lock()
+	defer um.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, u := range um.s.users {
+		if u.Email == created.Email {
+			return nil, &apperr.Error{Code: apperr.Conflict, Message: created.Email + " is already registered", Field: []string{"input", "email"}}
+		}
+	}
+	um.s.users = append(um.s.users, &created)
+	out := created
+	return &out, nil
+}
+
+func (um *UserManager) SetRole(ctx context.Context, id string, role model.Role) (*model.User, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	um.s.mu.Lock()
+	defer um.s.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, u := range um.s.users {
+		if u.ID == oid {
+			u.Role = role
+			updated := *u
+			return &updated, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (um *UserManager) Get(ctx context.Context, id string) (*model.User, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+
+	um.s.mu.RLock()
+	defer um.s.mu.RUnlock()
+	for _, u := range um.s.users {
+		if u.ID == oid {
+			found := *u
+			return &found, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (um *UserManager) ByEmail(ctx context.Context, email string) (*model.User, error) {
+	email = model.NormalizeEmail(email)
+
+	um.s.mu.RLock()
+	defer um.s.mu.RUnlock()
+	for _, u := range um.s.users {
+		if u.Email == email {
+			found := *u
+			return &found, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (um *UserManager) ByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.User, error) {
+	um.s.mu.RLock()
+	defer um.s.mu.RUnlock()
+
+	want := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	var users []*model.User
+	for _, u := range um.s.users {
+		if want[u.ID] {
+			user := *u
+			users = append(users, &user)
+		}
+	}
+	return users, nil
+}
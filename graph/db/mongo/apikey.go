@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKey interface {
+	// Create saves key under the ID the caller picked, which the key
+	// handed to the partner embeds.
+	Create(ctx context.Context, key *model.APIKey) (*model.APIKey, error)
+	// Rotate replaces the hash of the secret of a key, so the secret it
+	// was issued with stops working.
+	Rotate(ctx context.Context, id string, hash []byte, at time.Time) (*model.APIKey, error)
+	Revoke(ctx context.Context, id string, at time.Time) (*model.APIKey, error)
+	// Touch counts one more request made with the key with the given ID.
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error
+
+	Get(ctx context.Context, id string) (*model.APIKey, error)
+	// All returns the keys, newest first.
+	All(ctx context.Context, limit int, page int) ([]*model.APIKey, error)
+}
+
+type APIKeyManager struct {
+	Col      *mongo.Collection
+	Timeouts Timeouts
+}
+
+func NewAPIKeyManager(d *mongo.Database, t Timeouts) *APIKeyManager {
+	keys := d.Collection("api_keys")
+	return &APIKeyManager{Col: keys, Timeouts: t}
+}
+
+func (km *APIKeyManager) Create(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	l, cancel := context.WithTimeout(ctx, km.Timeouts.Write)
+	defer cancel()
+
+	if _, err := km.Col.InsertOne(l, key); err != nil {
+		return nil, err
+	}
+	created := *key
+	return &created, nil
+}
+
+func (km *APIKeyManager) Rotate(ctx context.Context, id string, hash []byte, at time.Time) (*model.APIKey, error) {
+	return km.update(ctx, id, bson.M{"$set": bson.M{"hash": hash, "rotated_at": at}})
+}
+
+func (km *APIKeyManager) Revoke(ctx context.Context, id string, at time.Time) (*model.APIKey, error) {
+	return km.update(ctx, id, bson.M{"$set": bson.M{"revoked_at": at}})
+}
+
+func (km *APIKeyManager) update(ctx context.Context, id string, update bson.M) (*model.APIKey, error) {
+	l, cancel := context.WithTimeout(ctx, km.Timeouts.Write)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var updated model.APIKey
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := km.Col.FindOneAndUpdate(l, bson.M{"_id": oid}, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (km *APIKeyManager) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	l, cancel := context.WithTimeout(ctx, km.Timeouts.Write)
+	defer cancel()
+
+	// $max keeps the latest time when requests finish out of order
+	update := bson.M{"$inc": bson.M{"requests": 1}, "$max": bson.M{"last_used_at": at}}
+	_, err := km.Col.UpdateOne(l, bson.M{"_id": id}, update)
+	return err
+}
+
+func (km *APIKeyManager) Get(ctx context.Context, id string) (*model.APIKey, error) {
+	l, cancel := context.WithTimeout(ctx, km.Timeouts.Read)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var key model.APIKey
+	if err := km.Col.FindOne(l, bson.M{"_id": oid}).Decode(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (km *APIKeyManager) All(ctx context.Context, limit int, page int) ([]*model.APIKey, error) {
+	l, cancel := context.WithTimeout(ctx, km.Timeouts.List)
+	defer cancel()
+
+	if page < 1 {
+		page = 1
+	}
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	cur, err := km.Col.Find(l, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	keys := []*model.APIKey{}
+	if err := cur.All(l, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
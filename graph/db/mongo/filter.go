@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/text"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The functions below translate the typed GraphQL filters into MongoDB
+// queries. Only the operators listed here can reach the database; client
+// strings are always used as values, never as operators.
+
+// recipeQuery builds the query for f. Matching on ingredients needs a look at
+// the ingredients collection, hence the context.
+func (tm *RecipeManager) recipeQuery(ctx context.Context, f *model.RecipeFilter) (bson.M, error) {
+	var and []bson.M
+	if f == nil {
+		return bson.M{}, nil
+	}
+
+	if f.ID != nil {
+		id, err := primitive.ObjectIDFromHex(*f.ID)
+		if err != nil {
+			return nil, apperr.Invalid([]string{"filter", "id"}, "invalid id")
+		}
+		and = append(and, bson.M{"_id": id})
+	}
+	and = append(and, stringQuery("name", f.Name)...)
+	and = append(and, stringQuery("slug", f.Slug)...)
+	and = append(and, timeQuery(f.CreatedAt)...)
+	for _, d := range []struct {
+		name, field string
+		f           *model.DurationFilter
+	}{{"prepTime", "prep_time", f.PrepTime}, {"cookTime", "cook_time", f.CookTime}, {"totalTime", "total_time", f.TotalTime}} {
+		q, err := durationQuery(d.field, []string{"filter", d.name}, d.f)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, q...)
+	}
+
+	if f.HasIngredient != nil {
+		foods, err := tm.foods().Distinct(ctx, "_id", bson.M{"keys": text.Slugify(*f.HasIngredient)})
+		if err != nil {
+			return nil, err
+		}
+		ids, err := tm.ingredients().Distinct(ctx, "recipe_id", bson.M{"food_id": bson.M{"$in": foods}})
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, bson.M{"_id": bson.M{"$in": ids}})
+	}
+	if f.FoodIDs != nil {
+		ids, err := tm.ingredients().Distinct(ctx, "recipe_id", bson.M{"food_id": bson.M{"$in": f.FoodIDs}})
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, bson.M{"_id": bson.M{"$in": ids}})
+	}
+
+	if f.Raw != nil {
+		and = append(and, bson.M(f.Raw))
+	}
+	return combine(and), nil
+}
+
+func ingredientQuery(f *model.IngredientFilter) (bson.M, error) {
+	var and []bson.M
+	if f == nil {
+		return bson.M{}, nil
+	}
+
+	if f.ID != nil {
+		id, err := primitive.ObjectIDFromHex(*f.ID)
+		if err != nil {
+			return nil, apperr.Invalid([]string{"filter", "id"}, "invalid id")
+		}
+		and = append(and, bson.M{"_id": id})
+	}
+	if f.RecipeID != nil {
+		id, err := primitive.ObjectIDFromHex(*f.RecipeID)
+		if err != nil {
+			return nil, apperr.Invalid([]string{"filter", "recipeID"}, "invalid recipeID")
+		}
+		and = append(and, bson.M{"recipe_id": id})
+	}
+	and = append(and, stringQuery("name", f.Name)...)
+	and = append(and, stringQuery("slug", f.Slug)...)
+	and = append(and, stringQuery("type", f.Type)...)
+	and = append(and, timeQuery(f.CreatedAt)...)
+
+	if f.Raw != nil {
+		and = append(and, bson.M(f.Raw))
+	}
+	return combine(and), nil
+}
+
+func foodQuery(f *model.FoodFilter) bson.M {
+	var and []bson.M
+	if f == nil {
+		return bson.M{}
+	}
+	and = append(and, stringQuery("name", f.Name)...)
+	and = append(and, stringQuery("slug", f.Slug)...)
+	and = append(and, stringQuery("category", f.Category)...)
+	return combine(and)
+}
+
+func stringQuery(field string, f *model.StringFilter) []bson.M {
+	var and []bson.M
+	if f == nil {
+		return nil
+	}
+	if f.Eq != nil {
+		and = append(and, bson.M{field: *f.Eq})
+	}
+	if f.In != nil {
+		and = append(and, bson.M{field: bson.M{"$in": f.In}})
+	}
+	if f.Contains != nil {
+		and = append(and, bson.M{field: primitive.Regex{Pattern: regexp.QuoteMeta(*f.Contains), Options: "i"}})
+	}
+	return and
+}
+
+// timeQuery filters on creation time, which ObjectIDs carry in their first
+// four bytes, so no extra field or index is needed.
+func timeQuery(r *model.TimeRange) []bson.M {
+	var and []bson.M
+	if r == nil {
+		return nil
+	}
+	if r.From != nil {
+		and = append(and, bson.M{"_id": bson.M{"$gte": primitive.NewObjectIDFromTimestamp(*r.From)}})
+	}
+	if r.To != nil {
+		and = append(and, bson.M{"_id": bson.M{"$lt": primitive.NewObjectIDFromTimestamp(*r.To)}})
+	}
+	return and
+}
+
+// durationQuery compares the seconds stored in field. Recipes without the
+// field never match, so "under 30 minutes" leaves out untimed recipes.
+func durationQuery(field string, path []string, f *model.DurationFilter) ([]bson.M, error) {
+	bounds, err := f.Bounds(path)
+	if err != nil {
+		return nil, err
+	}
+	var and []bson.M
+	for _, b := range bounds {
+		and = append(and, bson.M{field: bson.M{"$" + b.Op: b.Seconds}})
+	}
+	return and, nil
+}
+
+func combine(and []bson.M) bson.M {
+	switch len(and) {
+	case 0:
+		return bson.M{}
+	case 1:
+		return and[0]
+	}
+	return bson.M{"$and": and}
+}
+
+// recipeOrderFields are the document fields a RecipeOrder sorts on.
+var recipeOrderFields = map[model.RecipeOrderField]string{
+	model.RecipeOrderFieldName:      "name",
+	model.RecipeOrderFieldCreatedAt: "_id",
+	model.RecipeOrderFieldPrepTime:  "prep_time",
+	model.RecipeOrderFieldCookTime:  "cook_time",
+	model.RecipeOrderFieldTotalTime: "total_time",
+}
+
+// orderStages sort recipes by o, ties by _id. Recipes without the field
+// come last in either direction, where a plain $sort would put them first
+// when ascending.
+func orderStages(o *model.RecipeOrder) []interface{} {
+	if o == nil {
+		return nil
+	}
+	field := recipeOrderFields[o.Field]
+	dir := 1
+	if o.Direction == model.OrderDirectionDesc {
+		dir = -1
+	}
+	if field == "_id" {
+		return []interface{}{bson.M{"$sort": bson.D{{Key: "_id", Value: dir}}}}
+	}
+
+	missing := bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$" + field, nil}}, nil}}, 1, 0}}
+	return []interface{}{
+		bson.M{"$addFields": bson.M{"_missing": missing}},
+		bson.M{"$sort": bson.D{{Key: "_missing", Value: 1}, {Key: field, Value: dir}, {Key: "_id", Value: 1}}},
+		bson.M{"$project": bson.M{"_missing": 0}},
+	}
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/text"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Food interface {
+	Create(ctx context.Context, args *model.NewFood) (*model.Food, error)
+	Update(ctx context.Context, args *model.UpdateFood) (*model.Food, error)
+
+	// Get returns the food whose slug, name or synonym is name.
+	Get(ctx context.Context, name string) (*model.Food, error)
+	All(ctx context.Context, filter *model.FoodFilter, limit int, page int) ([]*model.Food, error)
+
+	// ByIDs returns the foods with the given IDs in one round-trip. Unknown
+	// IDs are left out.
+	ByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.Food, error)
+}
+
+// The foods collection is the ingredient catalog. Every ingredient line
+// points to a food through food_id, set when the line is written from the
+// food one of whose keys is the slug of the line's name; names the catalog
+// does not know yet get a food of their own. Renaming a food or adding
+// synonyms leaves the lines already pointing to it alone.
+//
+// keys carries a unique index, which EnsureIndexes creates, so two lines
+// written at once cannot add the same food twice.
+type FoodManager struct {
+	Col      *mongo.Collection
+	Timeouts Timeouts
+}
+
+func NewFoodManager(d *mongo.Database, t Timeouts) *FoodManager {
+	foods := d.Collection("foods")
+	return &FoodManager{Col: foods, Timeouts: t}
+}
+
+func (fm *FoodManager) Create(ctx context.Context, args *model.NewFood) (*model.Food, error) {
+	l, cancel := context.WithTimeout(ctx, fm.Timeouts.Write)
+	defer cancel()
+
+	food := model.MakeFood(args.Name, args.Synonyms, args.Category, args.Density)
+	food.CreatedBy = auth.UserID(ctx)
+	if err := checkKeys(l, fm.Col, food); err != nil {
+		return nil, err
+	}
+	if _, err := fm.Col.InsertOne(l, food); err != nil {
+		return nil, keyTaken(l, fm.Col, food, err)
+	}
+	return food, nil
+}
+
+func (fm *FoodManager) Update(ctx context.Context, args *model.UpdateFood) (*model.Food, error) {
+	l, cancel := context.WithTimeout(ctx, fm.Timeouts.Write)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
+	}
+	food := model.MakeFood(args.Name, args.Synonyms, args.Category, args.Density)
+	food.ID = id
+	if err := checkKeys(l, fm.Col, food); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":     food.Name,
+			"slug":     food.Slug,
+			"synonyms": food.Synonyms,
+			"category": food.Category,
+			"density":  food.Density,
+			"keys":     food.Keys,
+		},
+	}
+	var updated model.Food
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := fm.Col.FindOneAndUpdate(l, bson.M{"_id": id}, update, opts).Decode(&updated); err != nil {
+		return nil, keyTaken(l, fm.Col, food, err)
+	}
+	return &updated, nil
+}
+
+func (fm *FoodManager) Get(ctx context.Context, name string) (*model.Food, error) {
+	l, cancel := context.WithTimeout(ctx, fm.Timeouts.Read)
+	defer cancel()
+
+	var food model.Food
+	if err := fm.Col.FindOne(l, bson.M{"keys": text.Slugify(name)}).Decode(&food); err != nil {
+		return nil, err
+	}
+	return &food, nil
+}
+
+func (fm *FoodManager) All(ctx context.Context, filter *model.FoodFilter, limit int, page int) ([]*model.Food, error) {
+	l, cancel := context.WithTimeout(ctx, fm.Timeouts.List)
+	defer cancel()
+
+	if page < 1 {
+		page = 1
+	}
+	opts := options.Find().SetSort(bson.D{{"slug", 1}, {"_id", 1}}).SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	cur, err := fm.Col.Find(l, foodQuery(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	foods := []*model.Food{}
+	if err := cur.All(l, &foods); err != nil {
+		return nil, err
+	}
+	return foods, nil
+}
+
+func (fm *FoodManager) ByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.Food, error) {
+	l, cancel := context.WithTimeout(ctx, fm.Timeouts.Query)
+	defer cancel()
+
+	cur, err := fm.Col.Find(l, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var foods []*model.Food
+	if err := cur.All(l, &foods); err != nil {
+		return nil, err
+	}
+	return foods, nil
+}
+
+// checkKeys fails with a conflict when another food already goes by one of
+// the keys of food.
+func checkKeys(ctx context.Context, foods *mongo.Collection, food *model.Food) error {
+	var other model.Food
+	err := foods.FindOne(ctx, bson.M{"keys": bson.M{"$in": food.Keys}, "_id": bson.M{"$ne": food.ID}}).Decode(&other)
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return nil
+	case err != nil:
+		return err
+	}
+	return food.Conflict(&other)
+}
+
+// keyTaken turns err into the conflict checkKeys reports when it is the
+// unique index of keys turning away a write that raced another.
+func keyTaken(ctx context.Context, foods *mongo.Collection, food *model.Food, err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		if conflict := checkKeys(ctx, foods, food); conflict != nil {
+			return conflict
+		}
+	}
+	return err
+}
+
+// LinkFoods points each of the lines to the food its name stands for,
+// adding to the catalog the foods it does not have yet.
+func LinkFoods(ctx context.Context, foods *mongo.Collection, lines []*model.Ingredient) error {
+	for _, line := range lines {
+		id, err := foodFor(ctx, foods, line.Name)
+		if err != nil {
+			return err
+		}
+		line.FoodID = id
+	}
+	return nil
+}
+
+// foodFor returns the ID of the food name stands for, adding it to the
+// catalog when it is new, or nil for names without a slug.
+func foodFor(ctx context.Context, foods *mongo.Collection, name string) (*primitive.ObjectID, error) {
+	key := text.Slugify(name)
+	if key == "" {
+		return nil, nil
+	}
+	var found model.Food
+	err := foods.FindOne(ctx, bson.M{"keys": key}, options.FindOne().SetProjection(bson.M{"_id": 1})).Decode(&found)
+	if err == mongo.ErrNoDocuments {
+		food := model.MakeFood(name, nil, nil, nil)
+		if _, err = foods.InsertOne(ctx, food); err == nil {
+			return &food.ID, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+		// another write added the food since, use it
+		err = foods.FindOne(ctx, bson.M{"keys": key}, options.FindOne().SetProjection(bson.M{"_id": 1})).Decode(&found)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &found.ID, nil
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexes are those the managers rely on, by collection.
+var indexes = []struct {
+	collection string
+	models     []mongo.IndexModel
+}{
+	// two registrations at once cannot take the same address
+	{"users", []mongo.IndexModel{{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)}}},
+	// two lines written at once cannot add the same food twice
+	{"foods", []mongo.IndexModel{{Keys: bson.D{{Key: "keys", Value: 1}}, Options: options.Index().SetUnique(true)}}},
+	// cookableRecipes and the hasIngredient filter look lines up by food
+	{"ingredients", []mongo.IndexModel{{Keys: bson.D{{Key: "food_id", Value: 1}}}}},
+}
+
+// EnsureIndexes creates the indexes of d the managers rely on, leaving
+// those that already exist alone. It fails when the documents break a
+// unique index, which then has to be fixed by hand.
+func EnsureIndexes(ctx context.Context, d *mongo.Database) error {
+	for _, ix := range indexes {
+		if _, err := d.Collection(ix.collection).Indexes().CreateMany(ctx, ix.models); err != nil {
+			return fmt.Errorf("indexing %s: %w", ix.collection, err)
+		}
+	}
+	return nil
+}
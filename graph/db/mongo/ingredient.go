@@ -2,153 +2,228 @@ package db
 
 import (
 	"context"
-	"fmt"
-	"time"
 
 	pager "github.com/gobeam/mongo-go-pagination"
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
 	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/quantity"
 	"github.com/ottolauncher/recipes/utils/text"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Ingredient interface {
-	Create(ctx context.Context, args *model.NewIngredient) error
-	Bulk(ctx context.Context, args []*model.NewIngredient) error
-	Update(ctx context.Context, args *model.UpdateIngredient) error
-	Delete(ctx context.Context, filter map[string]interface{}) error
-
-	Get(ctx context.Context, filter map[string]interface{}) (*model.Ingredient, error)
-	All(ctx context.Context, filter map[string]interface{}, limit int, page int) ([]*model.Ingredient, error)
+	Create(ctx context.Context, args *model.NewIngredient) (*model.Ingredient, error)
+	Bulk(ctx context.Context, args []*model.NewIngredient) ([]*model.Ingredient, error)
+	Update(ctx context.Context, args *model.UpdateIngredient) (*model.Ingredient, error)
+	Delete(ctx context.Context, filter *model.IngredientFilter) (*model.Ingredient, error)
+
+	// Get, All and AllAfter read only the document fields behind the given
+	// GraphQL fields of Ingredient; nil fields read everything.
+	Get(ctx context.Context, filter *model.IngredientFilter, fields []string) (*model.Ingredient, error)
+	All(ctx context.Context, filter *model.IngredientFilter, fields []string, limit int, page int) ([]*model.Ingredient, error)
 	Search(ctx context.Context, query string, limit int, page int) ([]*model.Ingredient, error)
+
+	AllAfter(ctx context.Context, filter *model.IngredientFilter, fields []string, first int, after *primitive.ObjectID) ([]*model.Ingredient, bool, error)
+	SearchAfter(ctx context.Context, query string, first int, after *primitive.ObjectID) ([]*model.Ingredient, bool, error)
+
+	// ByRecipes returns the ingredient lines of all the given recipes in one
+	// round-trip, for the DataLoader to split by recipe.
+	ByRecipes(ctx context.Context, recipeIDs []primitive.ObjectID) ([]*model.Ingredient, error)
 }
 
 type IngredientManager struct {
 	Col *mongo.Collection
+	// Foods is the catalog the ingredients are linked to.
+	Foods    *mongo.Collection
+	Timeouts Timeouts
 }
 
-func NewIngredientManager(d *mongo.Database) *IngredientManager {
+func NewIngredientManager(d *mongo.Database, t Timeouts) *IngredientManager {
 	ingredients := d.Collection("ingredients")
-	return &IngredientManager{Col: ingredients}
+	return &IngredientManager{Col: ingredients, Foods: d.Collection("foods"), Timeouts: t}
 }
 
-func (im *IngredientManager) Bulk(ctx context.Context, args []*model.NewIngredient) error {
-	l, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+func (im *IngredientManager) Bulk(ctx context.Context, args []*model.NewIngredient) ([]*model.Ingredient, error) {
+	l, cancel := context.WithTimeout(ctx, im.Timeouts.List)
 	defer cancel()
 
 	src := []interface{}{}
+	var ingredients []*model.Ingredient
 
+	by := auth.UserID(ctx)
 	for _, args := range args {
 		slug := text.Slugify(args.Name)
-		ingredient := model.Ingredient{
-			Name:     args.Name,
-			Slug:     &slug,
-			Type:     args.Type,
-			Quantity: args.Quantity,
+		measure := quantity.Parse(args.Quantity)
+		ingredient := &model.Ingredient{
+			ID:        primitive.NewObjectID(),
+			Name:      args.Name,
+			Slug:      &slug,
+			Type:      args.Type,
+			Quantity:  args.Quantity,
+			Measure:   &measure,
+			CreatedBy: by,
+			UpdatedBy: by,
 		}
 
 		src = append(src, ingredient)
-
+		ingredients = append(ingredients, ingredient)
 	}
 
-	_, err := im.Col.InsertMany(l, src)
-	if err != nil {
+	// like recipes, a batch is written entirely or not at all, foods its
+	// lines created included
+	err := transaction(l, im.Col.Database().Client(), func(sc mongo.SessionContext) error {
+		if err := LinkFoods(sc, im.Foods, ingredients); err != nil {
+			return err
+		}
+		_, err := im.Col.InsertMany(sc, src)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return ingredients, nil
 }
-func (tm *IngredientManager) Create(ctx context.Context, args *model.NewIngredient) error {
-	l, cancel := context.WithTimeout(ctx, 350*time.Millisecond)
+func (tm *IngredientManager) Create(ctx context.Context, args *model.NewIngredient) (*model.Ingredient, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Write)
 	defer cancel()
 	slug := text.Slugify(args.Name)
-
-	ingredient := model.Ingredient{
-		Name:     args.Name,
-		Slug:     &slug,
-		Type:     args.Type,
-		Quantity: args.Quantity,
+	measure := quantity.Parse(args.Quantity)
+
+	by := auth.UserID(ctx)
+
+	ingredient := &model.Ingredient{
+		ID:        primitive.NewObjectID(),
+		Name:      args.Name,
+		Slug:      &slug,
+		Type:      args.Type,
+		Quantity:  args.Quantity,
+		Measure:   &measure,
+		CreatedBy: by,
+		UpdatedBy: by,
 	}
-	_, err := tm.Col.InsertOne(l, ingredient)
-	if err != nil {
+	// the food the line adds to the catalog goes only if the line does
+	err := transaction(l, tm.Col.Database().Client(), func(sc mongo.SessionContext) error {
+		if err := LinkFoods(sc, tm.Foods, []*model.Ingredient{ingredient}); err != nil {
+			return err
+		}
+		_, err := tm.Col.InsertOne(sc, ingredient)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return ingredient, nil
 }
 
-func (tm *IngredientManager) Update(ctx context.Context, args *model.UpdateIngredient) error {
-	l, cancel := context.WithTimeout(ctx, 350*time.Millisecond)
+func (tm *IngredientManager) Update(ctx context.Context, args *model.UpdateIngredient) (*model.Ingredient, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Write)
 	defer cancel()
 	slug := text.Slugify(args.Name)
 
-	ingredient := model.Ingredient{
-		Name:     args.Name,
-		Slug:     &slug,
-		Type:     args.Type,
-		Quantity: args.Quantity,
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
 	}
-	_, err := tm.Col.UpdateByID(l, args.ID, ingredient)
+	var updated model.Ingredient
+	// the food the line adds to the catalog goes only if the line is found
+	err = transaction(l, tm.Col.Database().Client(), func(sc mongo.SessionContext) error {
+		line := &model.Ingredient{Name: args.Name}
+		if err := LinkFoods(sc, tm.Foods, []*model.Ingredient{line}); err != nil {
+			return err
+		}
+		ingredient := bson.M{
+			"$set": bson.M{
+				"name":       args.Name,
+				"slug":       &slug,
+				"type":       args.Type,
+				"quantity":   args.Quantity,
+				"measure":    quantity.Parse(args.Quantity),
+				"food_id":    line.FoodID,
+				"updated_by": auth.UserID(ctx),
+			},
+		}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		return tm.Col.FindOneAndUpdate(sc, bson.M{"_id": id}, ingredient, opts).Decode(&updated)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &updated, nil
 }
 
-func (tm *IngredientManager) Delete(ctx context.Context, filter map[string]interface{}) error {
-	l, cancel := context.WithTimeout(ctx, 350*time.Millisecond)
+func (tm *IngredientManager) Delete(ctx context.Context, filter *model.IngredientFilter) (*model.Ingredient, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Write)
 	defer cancel()
-	if value, ok := filter["id"]; ok {
-		pk, err := primitive.ObjectIDFromHex(fmt.Sprintf("%s", value))
-		if err != nil {
+
+	query, err := ingredientQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	var deleted model.Ingredient
+	err = transaction(l, tm.Col.Database().Client(), func(sc mongo.SessionContext) error {
+		if err := tm.Col.FindOneAndDelete(sc, query).Decode(&deleted); err != nil {
 			return err
 		}
-		_, err = tm.Col.DeleteOne(l, pk)
-		if err != nil {
-			return err
+		if deleted.RecipeID.IsZero() {
+			return nil
 		}
+		// keep the recipe's ordered ingredient_ids, and the references of its
+		// steps, in step
+		recipes := tm.Col.Database().Collection("recipes")
+		pull := bson.M{"$pull": bson.M{"ingredient_ids": deleted.ID, "instructions.$[step].ingredient_ids": deleted.ID}}
+		opts := options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"step.ingredient_ids": deleted.ID}}})
+		_, err := recipes.UpdateOne(sc, bson.M{"_id": deleted.RecipeID}, pull, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return &deleted, nil
 }
 
-func (tm *IngredientManager) Get(ctx context.Context, filter map[string]interface{}) (*model.Ingredient, error) {
-	l, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+func (tm *IngredientManager) Get(ctx context.Context, filter *model.IngredientFilter, fields []string) (*model.Ingredient, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Read)
 	defer cancel()
 
-	var (
-		ingredient model.Ingredient
-		err        error
-	)
-	if id, ok := filter["id"]; ok {
-		i, err := primitive.ObjectIDFromHex(fmt.Sprintf("%s", id))
-		if err != nil {
-			return nil, err
-		}
-		err = tm.Col.FindOne(l, bson.M{"_id": i}).Decode(&ingredient)
-		if err != nil {
-			return nil, err
-		}
-	} else {
+	var ingredient model.Ingredient
 
-		err = tm.Col.FindOne(l, filter).Decode(&ingredient)
-		if err != nil {
-			return nil, err
-		}
+	query, err := ingredientQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.FindOne()
+	if project := projection(fields, ingredientFields); project != nil {
+		opts.SetProjection(project)
+	}
+	err = tm.Col.FindOne(l, query, opts).Decode(&ingredient)
+	if err != nil {
+		return nil, err
 	}
 	return &ingredient, nil
 
 }
 
-func (tm *IngredientManager) All(ctx context.Context, filter map[string]interface{}, limit int, page int) ([]*model.Ingredient, error) {
-	l, cancel := context.WithTimeout(ctx, 2000*time.Millisecond)
+func (tm *IngredientManager) All(ctx context.Context, filter *model.IngredientFilter, fields []string, limit int, page int) ([]*model.Ingredient, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.List)
 	defer cancel()
 
-	matchStage := bson.M{"$match": filter}
-	lookupStage := bson.M{"$lookup": bson.M{"from": "ingredients", "localField": "_id", "foreignField": "recipe_id", "as": "ingredients"}}
+	query, err := ingredientQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+	stages := []interface{}{bson.M{"$match": query}}
+	if project := projection(fields, ingredientFields); project != nil {
+		stages = append(stages, bson.M{"$project": project})
+	}
 
 	var ingredients []*model.Ingredient
-	cur, err := pager.New(tm.Col).Context(l).Limit(int64(limit)).Page(int64(page)).Aggregate(lookupStage, matchStage)
+	cur, err := pager.New(tm.Col).Context(l).Limit(int64(limit)).Page(int64(page)).Aggregate(stages...)
 
 	if err != nil {
 		return nil, err
@@ -157,7 +232,7 @@ func (tm *IngredientManager) All(ctx context.Context, filter map[string]interfac
 	for _, raw := range cur.Data {
 		var ingredient *model.Ingredient
 		if marshallErr := bson.Unmarshal(raw, &ingredient); marshallErr == nil {
-			ingredient.Pagination = *cur
+			ingredient.Pagination = pageOf(cur)
 			ingredients = append(ingredients, ingredient)
 		}
 	}
@@ -165,14 +240,13 @@ func (tm *IngredientManager) All(ctx context.Context, filter map[string]interfac
 }
 
 func (tm *IngredientManager) Search(ctx context.Context, query string, limit int, page int) ([]*model.Ingredient, error) {
-	l, cancel := context.WithTimeout(ctx, 1000*time.Millisecond)
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Query)
 	defer cancel()
 
 	matchStage := bson.M{"$match": bson.M{"$text": bson.M{"$search": query}}}
-	lookupStage := bson.M{"$lookup": bson.M{"from": "ingredients", "localField": "_id", "foreignField": "recipe_id", "as": "ingredients"}}
 
 	var ingredients []*model.Ingredient
-	cur, err := pager.New(tm.Col).Context(l).Limit(int64(limit)).Page(int64(page)).Aggregate(lookupStage, matchStage)
+	cur, err := pager.New(tm.Col).Context(l).Limit(int64(limit)).Page(int64(page)).Aggregate(matchStage)
 
 	if err != nil {
 		return nil, err
@@ -181,7 +255,7 @@ func (tm *IngredientManager) Search(ctx context.Context, query string, limit int
 	for _, raw := range cur.Data {
 		var ingredient *model.Ingredient
 		if marshallErr := bson.Unmarshal(raw, &ingredient); marshallErr == nil {
-			ingredient.Pagination = *cur
+			ingredient.Pagination = pageOf(cur)
 			ingredients = append(ingredients, ingredient)
 		}
 	}
@@ -192,3 +266,59 @@ func (tm *IngredientManager) Search(ctx context.Context, query string, limit int
 
 	return ingredients, nil
 }
+
+func (tm *IngredientManager) AllAfter(ctx context.Context, filter *model.IngredientFilter, fields []string, first int, after *primitive.ObjectID) ([]*model.Ingredient, bool, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.List)
+	defer cancel()
+
+	query, err := ingredientQuery(filter)
+	if err != nil {
+		return nil, false, err
+	}
+	return tm.after(l, query, fields, first, after)
+}
+
+func (tm *IngredientManager) SearchAfter(ctx context.Context, query string, first int, after *primitive.ObjectID) ([]*model.Ingredient, bool, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Query)
+	defer cancel()
+
+	return tm.after(l, bson.M{"$text": bson.M{"$search": query}}, nil, first, after)
+}
+
+func (tm *IngredientManager) after(ctx context.Context, query bson.M, fields []string, first int, after *primitive.ObjectID) ([]*model.Ingredient, bool, error) {
+	if after != nil {
+		query = bson.M{"$and": bson.A{query, bson.M{"_id": bson.M{"$gt": *after}}}}
+	}
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(first + 1))
+	if project := projection(fields, ingredientFields); project != nil {
+		opts.SetProjection(project)
+	}
+	cur, err := tm.Col.Find(ctx, query, opts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ingredients []*model.Ingredient
+	if err := cur.All(ctx, &ingredients); err != nil {
+		return nil, false, err
+	}
+	if len(ingredients) > first {
+		return ingredients[:first], true, nil
+	}
+	return ingredients, false, nil
+}
+
+func (tm *IngredientManager) ByRecipes(ctx context.Context, recipeIDs []primitive.ObjectID) ([]*model.Ingredient, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Query)
+	defer cancel()
+
+	cur, err := tm.Col.Find(l, bson.M{"recipe_id": bson.M{"$in": recipeIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var ingredients []*model.Ingredient
+	if err := cur.All(l, &ingredients); err != nil {
+		return nil, err
+	}
+	return ingredients, nil
+}
@@ -10,12 +10,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
-// TODO add env support
-
-const uri = "mongodb://127.0.0.1:27017/recipedb"
-
-func Init() *mongo.Client {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func Init(uri string, timeout time.Duration) *mongo.Client {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	client, err := mongo.NewClient(options.Client().ApplyURI(uri))
 	if err != nil {
@@ -32,3 +28,16 @@ func Init() *mongo.Client {
 	log.Println("Connected Successfully")
 	return client
 }
+
+// Timeouts bound how long the managers wait for MongoDB, by kind of
+// operation. Transactions share the timeout of the write they make.
+type Timeouts struct {
+	// Write bounds creating, changing or deleting a document.
+	Write time.Duration
+	// Read bounds reading one document.
+	Read time.Duration
+	// Query bounds searches and the batched reads of the DataLoaders.
+	Query time.Duration
+	// List bounds listing pages of documents and writing batches of them.
+	List time.Duration
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MealPlan interface {
+	Create(ctx context.Context, args *model.NewMealPlan, feedToken string) (*model.MealPlan, error)
+	// Plan adds a meal to a plan. It does not check that the recipe exists.
+	Plan(ctx context.Context, args *model.PlanMeal) (*model.MealPlan, error)
+	// Move puts a meal of a plan on another day or slot.
+	Move(ctx context.Context, args *model.MoveMeal) (*model.MealPlan, error)
+	Remove(ctx context.Context, planID string, entryID string) (*model.MealPlan, error)
+	Delete(ctx context.Context, id string) (*model.MealPlan, error)
+	// ResetFeed replaces the token of the calendar feed of a plan, so the
+	// address it was served at no longer works.
+	ResetFeed(ctx context.Context, id string, feedToken string) (*model.MealPlan, error)
+
+	Get(ctx context.Context, id string) (*model.MealPlan, error)
+	// All returns the plans of owner, newest first.
+	All(ctx context.Context, owner primitive.ObjectID, limit int, page int) ([]*model.MealPlan, error)
+}
+
+type MealPlanManager struct {
+	Col      *mongo.Collection
+	Timeouts Timeouts
+}
+
+func NewMealPlanManager(d *mongo.Database, t Timeouts) *MealPlanManager {
+	plans := d.Collection("meal_plans")
+	return &MealPlanManager{Col: plans, Timeouts: t}
+}
+
+func (mm *MealPlanManager) Create(ctx context.Context, args *model.NewMealPlan, feedToken string) (*model.MealPlan, error) {
+	l, cancel := context.WithTimeout(ctx, mm.Timeouts.Write)
+	defer cancel()
+
+	plan := &model.MealPlan{ID: primitive.NewObjectID(), Name: args.Name, Entries: []*model.MealPlanEntry{}, FeedToken: feedToken, CreatedBy: auth.UserID(ctx)}
+	if _, err := mm.Col.InsertOne(l, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (mm *MealPlanManager) Plan(ctx context.Context, args *model.PlanMeal) (*model.MealPlan, error) {
+	l, cancel := context.WithTimeout(ctx, mm.Timeouts.Write)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(args.PlanID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "planID"}, "invalid id %q", args.PlanID)
+	}
+	recipe, err := primitive.ObjectIDFromHex(args.RecipeID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "recipeID"}, "invalid id %q", args.RecipeID)
+	}
+
+	entry := &model.MealPlanEntry{ID: primitive.NewObjectID(), RecipeID: recipe, Date: args.Date, Slot: args.Slot, Servings: args.Servings}
+	var updated model.MealPlan
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$push": bson.M{"entries": entry}}
+	if err := mm.Col.FindOneAndUpdate(l, bson.M{"_id": id}, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (mm *MealPlanManager) Move(ctx context.Context, args *model.MoveMeal) (*model.MealPlan, error) {
+	l, cancel := context.WithTimeout(ctx, mm.Timeouts.Write)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(args.PlanID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "planID"}, "invalid id %q", args.PlanID)
+	}
+	entry, err := primitive.ObjectIDFromHex(args.EntryID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "entryID"}, "invalid id %q", args.EntryID)
+	}
+
+	var updated model.MealPlan
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	query := bson.M{"_id": id, "entries._id": entry}
+	update := bson.M{"$set": bson.M{"entries.$.date": args.Date, "entries.$.slot": args.Slot}}
+	if err := mm.Col.FindOneAndUpdate(l, query, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (mm *MealPlanManager) Remove(ctx context.Context, planID string, entryID string) (*model.MealPlan, error) {
+	l, cancel := context.WithTimeout(ctx, mm.Timeouts.Write)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(planID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"planID"}, "invalid id %q", planID)
+	}
+	entry, err := primitive.ObjectIDFromHex(entryID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"entryID"}, "invalid id %q", entryID)
+	}
+
+	var updated model.MealPlan
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	query := bson.M{"_id": id, "entries._id": entry}
+	update := bson.M{"$pull": bson.M{"entries": bson.M{"_id": entry}}}
+	if err := mm.Col.FindOneAndUpdate(l, query, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (mm *MealPlanManager) Delete(ctx context.Context, id string) (*model.MealPlan, error) {
+	l, cancel := context.WithTimeout(ctx, mm.Timeouts.Write)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var deleted model.MealPlan
+	if err := mm.Col.FindOneAndDelete(l, bson.M{"_id": oid}).Decode(&deleted); err != nil {
+		return nil, err
+	}
+	return &deleted, nil
+}
+
+func (mm *MealPlanManager) ResetFeed(ctx context.Context, id string, feedToken string) (*model.MealPlan, error) {
+	l, cancel := context.WithTimeout(ctx, mm.Timeouts.Write)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"planID"}, "invalid id %q", id)
+	}
+	var updated model.MealPlan
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$set": bson.M{"feed_token": feedToken}}
+	if err := mm.Col.FindOneAndUpdate(l, bson.M{"_id": oid}, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (mm *MealPlanManager) Get(ctx context.Context, id string) (*model.MealPlan, error) {
+	l, cancel := context.WithTimeout(ctx, mm.Timeouts.Read)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var plan model.MealPlan
+	if err := mm.Col.FindOne(l, bson.M{"_id": oid}).Decode(&plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (mm *MealPlanManager) All(ctx context.Context, owner primitive.ObjectID, limit int, page int) ([]*model.MealPlan, error) {
+	l, cancel := context.WithTimeout(ctx, mm.Timeouts.List)
+	defer cancel()
+
+	if page < 1 {
+		page = 1
+	}
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	cur, err := mm.Col.Find(l, bson.M{"created_by": owner}, opts)
+	if err != nil {
+		return nil, err
+	}
+	plans := []*model.MealPlan{}
+	if err := cur.All(l, &plans); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Pantry interface {
+	Create(ctx context.Context, args *model.NewPantry) (*model.Pantry, error)
+	// Update replaces the name and the items of a pantry. An item keeps its
+	// ID when the input names one the pantry has.
+	Update(ctx context.Context, args *model.UpdatePantry) (*model.Pantry, error)
+	Delete(ctx context.Context, id string) (*model.Pantry, error)
+
+	Get(ctx context.Context, id string) (*model.Pantry, error)
+	// All returns the pantries of owner by name.
+	All(ctx context.Context, owner primitive.ObjectID, limit int, page int) ([]*model.Pantry, error)
+}
+
+// Pantries embed their items, each pointing to its food in the catalog
+// like an ingredient line.
+type PantryManager struct {
+	Col      *mongo.Collection
+	Foods    *mongo.Collection
+	Timeouts Timeouts
+}
+
+func NewPantryManager(d *mongo.Database, t Timeouts) *PantryManager {
+	pantries := d.Collection("pantries")
+	return &PantryManager{Col: pantries, Foods: d.Collection("foods"), Timeouts: t}
+}
+
+func (pm *PantryManager) Create(ctx context.Context, args *model.NewPantry) (*model.Pantry, error) {
+	l, cancel := context.WithTimeout(ctx, pm.Timeouts.Write)
+	defer cancel()
+
+	pantry := &model.Pantry{
+		ID:        primitive.NewObjectID(),
+		Name:      args.Name,
+		Items:     model.NewPantryItems(args.Items, nil),
+		CreatedBy: auth.UserID(ctx),
+	}
+	if err := pm.link(l, pantry.Items); err != nil {
+		return nil, err
+	}
+	if _, err := pm.Col.InsertOne(l, pantry); err != nil {
+		return nil, err
+	}
+	return pantry, nil
+}
+
+func (pm *PantryManager) Update(ctx context.Context, args *model.UpdatePantry) (*model.Pantry, error) {
+	l, cancel := context.WithTimeout(ctx, pm.Timeouts.Write)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
+	}
+	var current model.Pantry
+	if err := pm.Col.FindOne(l, bson.M{"_id": id}).Decode(&current); err != nil {
+		return nil, err
+	}
+	items := model.NewPantryItems(args.Items, current.Items)
+	if err := pm.link(l, items); err != nil {
+		return nil, err
+	}
+
+	var updated model.Pantry
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$set": bson.M{"name": args.Name, "items": items}}
+	if err := pm.Col.FindOneAndUpdate(l, bson.M{"_id": id}, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (pm *PantryManager) Delete(ctx context.Context, id string) (*model.Pantry, error) {
+	l, cancel := context.WithTimeout(ctx, pm.Timeouts.Write)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var deleted model.Pantry
+	if err := pm.Col.FindOneAndDelete(l, bson.M{"_id": oid}).Decode(&deleted); err != nil {
+		return nil, err
+	}
+	return &deleted, nil
+}
+
+func (pm *PantryManager) Get(ctx context.Context, id string) (*model.Pantry, error) {
+	l, cancel := context.WithTimeout(ctx, pm.Timeouts.Read)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var pantry model.Pantry
+	if err := pm.Col.FindOne(l, bson.M{"_id": oid}).Decode(&pantry); err != nil {
+		return nil, err
+	}
+	return &pantry, nil
+}
+
+func (pm *PantryManager) All(ctx context.Context, owner primitive.ObjectID, limit int, page int) ([]*model.Pantry, error) {
+	l, cancel := context.WithTimeout(ctx, pm.Timeouts.List)
+	defer cancel()
+
+	if page < 1 {
+		page = 1
+	}
+	opts := options.Find().SetSort(bson.D{{"name", 1}, {"_id", 1}}).SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	cur, err := pm.Col.Find(l, bson.M{"created_by": owner}, opts)
+	if err != nil {
+		return nil, err
+	}
+	pantries := []*model.Pantry{}
+	if err := cur.All(l, &pantries); err != nil {
+		return nil, err
+	}
+	return pantries, nil
+}
+
+func (pm *PantryManager) link(ctx context.Context, items []*model.PantryItem) error {
+	for _, i := range items {
+		id, err := foodFor(ctx, pm.Foods, i.Name)
+		if err != nil {
+			return err
+		}
+		i.FoodID = id
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package db
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Read methods take the GraphQL fields the client selected on the type they
+// return, as collected by preloads.GetFields, and only read the document
+// fields behind them. A nil field set reads everything.
+
+// recipeFields maps the fields of the Recipe type to the document fields
+// they are resolved from. The ordered ingredient_ids come along with the
+// ingredients so the lookup result can be put in order.
+var recipeFields = map[string][]string{
+	"id":               {"_id"},
+	"createdAt":        {"_id"},
+	"name":             {"name"},
+	"slug":             {"slug"},
+	"timers":           {"timers", "timings"},
+	"timings":          {"timers", "timings"},
+	"prepTime":         {"prep_time"},
+	"cookTime":         {"cook_time"},
+	"totalTime":        {"total_time"},
+	"steps":            {"steps", "instructions"},
+	"instructions":     {"steps", "instructions"},
+	"imageURL":         {"imageURL"},
+	"originalURL":      {"originalURL"},
+	"servings":         {"servings"},
+	"yield":            {"yield"},
+	"ingredients":      {"ingredient_ids"},
+	"ingredientIDS":    {"ingredient_ids"},
+	"ingredientGroups": {"ingredient_ids", "ingredient_groups"},
+	"createdBy":        {"created_by"},
+	"updatedBy":        {"updated_by"},
+	"locked":           {"locked"},
+	"moderationNote":   {"moderation_note"},
+}
+
+var ingredientFields = map[string][]string{
+	"id":        {"_id"},
+	"createdAt": {"_id"},
+	"name":      {"name"},
+	"slug":      {"slug"},
+	"type":      {"type"},
+	"quantity":  {"quantity"},
+	"amount":    {"quantity", "measure"},
+	"amountMax": {"quantity", "measure"},
+	"unit":      {"quantity", "measure"},
+	"display":   {"quantity", "measure"},
+	"group":     {"group"},
+	"food":      {"food_id"},
+	"recipeID":  {"recipe_id"},
+	"recipe":    {"recipe_id"},
+	"createdBy": {"created_by"},
+	"updatedBy": {"updated_by"},
+}
+
+// projection returns the $project document reading what fields need, or
+// nil to read whole documents. _id is always part of it since cursors and
+// joins are keyed on it.
+func projection(fields []string, known map[string][]string) bson.M {
+	if fields == nil {
+		return nil
+	}
+	project := bson.M{"_id": 1}
+	for _, f := range fields {
+		for _, name := range known[f] {
+			project[name] = 1
+		}
+	}
+	return project
+}
+
+// recipeStages returns the pipeline stages that follow the $match of a
+// recipe read: the projection, and the ingredients $lookup only when the
+// client asked for the ingredients. An empty field set projects _id alone.
+func recipeStages(fields []string) []bson.D {
+	var stages []bson.D
+	if project := projection(fields, recipeFields); project != nil {
+		stages = append(stages, bson.D{{"$project", project}})
+	}
+	if selected(fields, "ingredients") {
+		stages = append(stages, ingredientsLookup)
+	}
+	return stages
+}
+
+func selected(fields []string, name string) bool {
+	if fields == nil {
+		return true
+	}
+	for _, f := range fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
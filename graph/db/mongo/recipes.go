@@ -2,216 +2,342 @@ package db
 
 import (
 	"context"
-	"fmt"
-	"time"
 
 	pager "github.com/gobeam/mongo-go-pagination"
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
 	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/quantity"
 	"github.com/ottolauncher/recipes/utils/text"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type IRecipe interface {
-	Create(ctx context.Context, args *model.NewRecipe) error
-	Bulk(ctx context.Context, args []*model.NewRecipe) error
-	Update(ctx context.Context, args *model.UpdateRecipe) error
-	Delete(ctx context.Context, filter map[string]interface{}) error
-	Get(ctx context.Context, filter map[string]interface{}) (*model.Recipe, error)
-	All(ctx context.Context, filter map[string]interface{}, limit int, page int) ([]*model.Recipe, error)
+	Create(ctx context.Context, args *model.NewRecipe) (*model.Recipe, error)
+	Bulk(ctx context.Context, args []*model.NewRecipe) ([]*model.Recipe, error)
+	Update(ctx context.Context, args *model.UpdateRecipe) (*model.Recipe, error)
+	Delete(ctx context.Context, filter *model.RecipeFilter) (*model.Recipe, error)
+	// Moderate sets whether a recipe is locked and the note saying why. The
+	// recipe returned lacks its ingredients.
+	Moderate(ctx context.Context, args *model.ModerateRecipe) (*model.Recipe, error)
+	// Get, All and AllAfter read only the document fields behind the given
+	// GraphQL fields of Recipe, and join the ingredients only when they are
+	// among them; nil fields read everything.
+	Get(ctx context.Context, filter *model.RecipeFilter, fields []string) (*model.Recipe, error)
+	All(ctx context.Context, filter *model.RecipeFilter, order *model.RecipeOrder, fields []string, limit int, page int) ([]*model.Recipe, error)
 	Search(ctx context.Context, query string, limit int, page int) ([]*model.Recipe, error)
+
+	// AllAfter and SearchAfter page by keyset on _id: they return up to first
+	// recipes whose ID sorts after the given one, and whether more follow.
+	AllAfter(ctx context.Context, filter *model.RecipeFilter, fields []string, first int, after *primitive.ObjectID) ([]*model.Recipe, bool, error)
+	SearchAfter(ctx context.Context, query string, first int, after *primitive.ObjectID) ([]*model.Recipe, bool, error)
+
+	// ByIDs returns the recipes with the given IDs, without their
+	// ingredients, in one round-trip. Unknown IDs are left out.
+	ByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.Recipe, error)
 }
 
 type RecipeManager struct {
-	Col *mongo.Collection
-	DB  *mongo.Database
+	Col      *mongo.Collection
+	DB       *mongo.Database
+	Timeouts Timeouts
 }
 
-func NewRecipeManager(d *mongo.Database) *RecipeManager {
+func NewRecipeManager(d *mongo.Database, t Timeouts) *RecipeManager {
 	recipes := d.Collection("recipes")
-	return &RecipeManager{Col: recipes, DB: d}
+	return &RecipeManager{Col: recipes, DB: d, Timeouts: t}
+}
+
+// Ingredient lines live in the ingredients collection and point back to
+// their recipe through recipe_id; a recipe document only keeps the ordered
+// ingredient_ids. Reads that select the ingredients join the two with
+// ingredientsLookup; the others leave Ingredients nil, and the GraphQL layer
+// batches any it still needs through a DataLoader. Nothing embeds
+// ingredients in the recipe document any more (cmd/migrate rewrites
+// documents written that way by older versions).
+var ingredientsLookup = bson.D{{"$lookup", bson.M{"from": "ingredients", "localField": "_id", "foreignField": "recipe_id", "as": "ingredients"}}}
+
+func (tm *RecipeManager) ingredients() *mongo.Collection {
+	return tm.DB.Collection("ingredients")
+}
+
+func (tm *RecipeManager) foods() *mongo.Collection {
+	return tm.DB.Collection("foods")
 }
 
-func (tm *RecipeManager) Bulk(ctx context.Context, args []*model.NewRecipe) error {
-	_, cancel := context.WithTimeout(ctx, 2*time.Second)
+func (tm *RecipeManager) Bulk(ctx context.Context, args []*model.NewRecipe) ([]*model.Recipe, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.List)
 	defer cancel()
 
 	src := []interface{}{}
+	lsrc := []interface{}{}
+	var (
+		recipes []*model.Recipe
+		lines   []*model.Ingredient
+	)
 
+	by := auth.UserID(ctx)
 	for _, v := range args {
-		lsrc := []interface{}{}
-		slug := text.Slugify(v.Name)
-		id := primitive.NewObjectID()
-
-		for _, i := range v.Ingredients {
-			slg := text.Slugify(i.Name)
-			lsrc = append(lsrc, bson.M{
-				"name":      i.Name,
-				"slug":      &slg,
-				"type":      i.Type,
-				"quantity":  i.Quantity,
-				"recipe_id": id,
-			})
+		recipe := newRecipe(v, by)
+		for _, i := range recipe.Ingredients {
+			lsrc = append(lsrc, i)
 		}
+		lines = append(lines, recipe.Ingredients...)
+		src = append(src, recipeDocument(recipe))
+		recipes = append(recipes, recipe)
+	}
 
-		res, err := tm.DB.Collection("ingredients").InsertMany(context.TODO(), lsrc)
-		if err != nil {
+	err := transaction(l, tm.DB.Client(), func(sc mongo.SessionContext) error {
+		if err := LinkFoods(sc, tm.foods(), lines); err != nil {
 			return err
 		}
-
-		input := bson.M{
-			"_id":           id,
-			"name":          v.Name,
-			"slug":          &slug,
-			"timers":        v.Timers,
-			"steps":         v.Steps,
-			"imageURL":      v.ImageURL,
-			"originalURL":   &v.OriginalURL,
-			"ingredientIDs": res.InsertedIDs,
+		if len(lsrc) > 0 {
+			if _, err := tm.ingredients().InsertMany(sc, lsrc); err != nil {
+				return err
+			}
 		}
-		src = append(src, input)
-	}
-
-	_, err := tm.Col.InsertMany(context.TODO(), src)
-	if err != nil {
+		_, err := tm.Col.InsertMany(sc, src)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return recipes, nil
 }
 
-func (tm *RecipeManager) Create(ctx context.Context, args *model.NewRecipe) error {
-	l, cancel := context.WithTimeout(ctx, 350*time.Millisecond)
+func (tm *RecipeManager) Create(ctx context.Context, args *model.NewRecipe) (*model.Recipe, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Write)
 	defer cancel()
-	slug := text.Slugify(args.Name)
 
-	var ingredients []model.Ingredient
-
-	for _, i := range args.Ingredients {
-		slg := text.Slugify(i.Name)
-		ingredients = append(ingredients, model.Ingredient{
-			ID:       primitive.NewObjectID(),
-			Name:     i.Name,
-			Slug:     &slg,
-			Type:     i.Type,
-			Quantity: i.Quantity,
-		})
-	}
-	input := bson.M{
-		"name":        args.Name,
-		"slug":        &slug,
-		"timers":      args.Timers,
-		"steps":       args.Steps,
-		"imageURL":    args.ImageURL,
-		"originalURL": &args.OriginalURL,
-		"ingredients": ingredients,
-	}
-
-	_, err := tm.Col.InsertOne(l, input)
-	if err != nil {
-		return err
+	recipe := newRecipe(args, auth.UserID(ctx))
+	src := []interface{}{}
+	for _, i := range recipe.Ingredients {
+		src = append(src, i)
 	}
 
-	return nil
+	err := transaction(l, tm.DB.Client(), func(sc mongo.SessionContext) error {
+		if err := LinkFoods(sc, tm.foods(), recipe.Ingredients); err != nil {
+			return err
+		}
+		if len(src) > 0 {
+			if _, err := tm.ingredients().InsertMany(sc, src); err != nil {
+				return err
+			}
+		}
+		_, err := tm.Col.InsertOne(sc, recipeDocument(recipe))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recipe, nil
 }
 
-func (tm *RecipeManager) Update(ctx context.Context, args *model.UpdateRecipe) error {
-	l, cancel := context.WithTimeout(ctx, 350*time.Millisecond)
+// Update replaces the recipe fields and its ingredient lines. A line keeps
+// its ID when the input names one that already belongs to this recipe;
+// every other line is stored as a new ingredient.
+func (tm *RecipeManager) Update(ctx context.Context, args *model.UpdateRecipe) (*model.Recipe, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Write)
 	defer cancel()
-	slug := text.Slugify(args.Name)
-	var ingredients []model.Ingredient
-
-	for _, i := range args.Ingredients {
-		slg := text.Slugify(i.Name)
-		ingredients = append(ingredients, model.Ingredient{
-			Name:     i.Name,
-			Slug:     &slg,
-			Type:     i.Type,
-			Quantity: i.Quantity,
-		})
-	}
-
-	recipe := bson.D{
-		{"$set",
-			bson.D{{"name", args.Name},
-				{"slug", slug},
-				{"timers", args.Timers},
-				{"steps", args.Steps},
-				{"imageURL", args.ImageURL},
-				{"originalURL", &args.OriginalURL},
-				{"ingredients", ingredients},
-			},
-		},
-	}
 
 	id, err := primitive.ObjectIDFromHex(args.ID)
 	if err != nil {
-		return err
-	}
-	_, err = tm.Col.UpdateByID(l, id, recipe)
-	if err != nil {
-		return err
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
 	}
-	return nil
-}
+	slug := text.Slugify(args.Name)
+	by := auth.UserID(ctx)
 
-func (tm *RecipeManager) Delete(ctx context.Context, filter map[string]interface{}) error {
-	l, cancel := context.WithTimeout(ctx, 350*time.Millisecond)
-	defer cancel()
-	if value, ok := filter["id"]; ok {
-		pk, err := primitive.ObjectIDFromHex(fmt.Sprintf("%s", value))
-		if err != nil {
+	var updated *model.Recipe
+	err = transaction(l, tm.DB.Client(), func(sc mongo.SessionContext) error {
+		if err := tm.Col.FindOne(sc, bson.M{"_id": id}).Err(); err != nil {
 			return err
 		}
-		_, err = tm.Col.DeleteOne(l, pk)
+
+		// kept lines keep their author
+		cur, err := tm.ingredients().Find(sc, bson.M{"recipe_id": id}, options.Find().SetProjection(bson.M{"created_by": 1}))
 		if err != nil {
 			return err
 		}
+		var owned []*model.Ingredient
+		if err := cur.All(sc, &owned); err != nil {
+			return err
+		}
+		keep := make(map[primitive.ObjectID]*model.Ingredient, len(owned))
+		for _, i := range owned {
+			keep[i.ID] = i
+		}
+
+		src := []interface{}{}
+		ids := []primitive.ObjectID{}
+		var lines []*model.Ingredient
+		for _, line := range args.Lines() {
+			i := line.Ingredient
+			createdBy := by
+			iid, err := primitive.ObjectIDFromHex(i.ID)
+			if kept, ok := keep[iid]; err == nil && ok {
+				createdBy = kept.CreatedBy
+			} else {
+				iid = primitive.NewObjectID()
+			}
+			delete(keep, iid)
+			ingredient := newIngredient(iid, id, i.Name, i.Type, i.Quantity)
+			ingredient.Group = line.Group
+			ingredient.CreatedBy, ingredient.UpdatedBy = createdBy, by
+			src = append(src, ingredient)
+			lines = append(lines, ingredient)
+			ids = append(ids, iid)
+		}
+		if err := LinkFoods(sc, tm.foods(), lines); err != nil {
+			return err
+		}
+
+		if _, err := tm.ingredients().DeleteMany(sc, bson.M{"recipe_id": id}); err != nil {
+			return err
+		}
+		if len(src) > 0 {
+			if _, err := tm.ingredients().InsertMany(sc, src); err != nil {
+				return err
+			}
+		}
+
+		timers := model.NewTimers(args.Timers, args.Timings)
+		prep, cook, total := model.TimerTotals(timers)
+		set := bson.M{
+			"name":              args.Name,
+			"slug":              &slug,
+			"timings":           timers,
+			"prep_time":         prep,
+			"cook_time":         cook,
+			"total_time":        total,
+			"instructions":      model.NewSteps(args.Steps, args.Instructions, ids),
+			"imageURL":          args.ImageURL,
+			"originalURL":       &args.OriginalURL,
+			"servings":          args.Servings,
+			"yield":             args.Yield,
+			"ingredient_ids":    ids,
+			"ingredient_groups": args.GroupNames(),
+			"updated_by":        by,
+		}
+		// superseded by instructions
+		unset := bson.M{"steps": ""}
+		// timer texts that are no duration stay, the rest are superseded by
+		// timings; a path cannot be both set and unset
+		if untimed := model.Untimed(args.Timers); untimed != nil {
+			set["timers"] = untimed
+		} else {
+			unset["timers"] = ""
+		}
+		update := bson.M{"$set": set, "$unset": unset}
+		if _, err := tm.Col.UpdateOne(sc, bson.M{"_id": id}, update); err != nil {
+			return err
+		}
+
+		updated, err = tm.one(sc, bson.M{"_id": id}, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return updated, nil
 }
 
-func (tm *RecipeManager) Get(ctx context.Context, filter map[string]interface{}) (*model.Recipe, error) {
-	l, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+func (tm *RecipeManager) Moderate(ctx context.Context, args *model.ModerateRecipe) (*model.Recipe, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Write)
 	defer cancel()
 
-	var (
-		recipe model.Recipe
-		err    error
-	)
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "id"}, "invalid id %q", args.ID)
+	}
+	update := bson.M{"$set": bson.M{"locked": args.Locked, "moderation_note": args.Note}}
+	var updated model.Recipe
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := tm.Col.FindOneAndUpdate(l, bson.M{"_id": id}, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
 
-	if id, ok := filter["id"]; ok {
-		i, err := primitive.ObjectIDFromHex(fmt.Sprintf("%s", id))
+// Delete removes the recipe and the ingredient lines that belong to it.
+func (tm *RecipeManager) Delete(ctx context.Context, filter *model.RecipeFilter) (*model.Recipe, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Write)
+	defer cancel()
+
+	query, err := tm.recipeQuery(l, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted *model.Recipe
+	err = transaction(l, tm.DB.Client(), func(sc mongo.SessionContext) error {
+		deleted, err = tm.one(sc, query, nil)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		err = tm.Col.FindOne(l, bson.M{"_id": i}).Decode(&recipe)
-		if err != nil {
-			return nil, err
+		if _, err := tm.Col.DeleteOne(sc, bson.M{"_id": deleted.ID}); err != nil {
+			return err
 		}
-	} else {
+		_, err := tm.ingredients().DeleteMany(sc, bson.M{"recipe_id": deleted.ID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
 
-		err = tm.Col.FindOne(l, filter).Decode(&recipe)
-		if err != nil {
-			return nil, err
-		}
+func (tm *RecipeManager) Get(ctx context.Context, filter *model.RecipeFilter, fields []string) (*model.Recipe, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Read)
+	defer cancel()
+
+	query, err := tm.recipeQuery(l, filter)
+	if err != nil {
+		return nil, err
 	}
+	return tm.one(l, query, fields)
+}
 
-	return &recipe, nil
+// one returns the first recipe matching query, reading only fields.
+func (tm *RecipeManager) one(ctx context.Context, query bson.M, fields []string) (*model.Recipe, error) {
+	pipeline := mongo.Pipeline{
+		{{"$match", query}},
+		{{"$limit", 1}},
+	}
+	pipeline = append(pipeline, recipeStages(fields)...)
+	cur, err := tm.Col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
 
+	var recipes []*model.Recipe
+	if err := cur.All(ctx, &recipes); err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	return ordered(recipes[0]), nil
 }
 
-func (tm *RecipeManager) All(ctx context.Context, filter map[string]interface{}, limit int, page int) ([]*model.Recipe, error) {
-	l, cancel := context.WithTimeout(ctx, 2*time.Second)
+func (tm *RecipeManager) All(ctx context.Context, filter *model.RecipeFilter, order *model.RecipeOrder, fields []string, limit int, page int) ([]*model.Recipe, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.List)
 	defer cancel()
 
-	matchStage := bson.M{"$match": filter}
-	lookupStage := bson.M{"$lookup": bson.M{"from": "ingredients", "localField": "_id", "foreignField": "recipe_id", "as": "ingredients"}}
-	// matchStage := bson.D{{"$match"}}
+	query, err := tm.recipeQuery(l, filter)
+	if err != nil {
+		return nil, err
+	}
+	stages := append([]interface{}{bson.M{"$match": query}}, orderStages(order)...)
+	for _, stage := range recipeStages(fields) {
+		stages = append(stages, stage)
+	}
 
 	var recipes []*model.Recipe
-	cur, err := pager.New(tm.Col).Context(l).Limit(int64(limit)).Page(int64(page)).Aggregate(lookupStage, matchStage)
+	cur, err := pager.New(tm.Col).Context(l).Limit(int64(limit)).Page(int64(page)).Aggregate(stages...)
 
 	if err != nil {
 		return nil, err
@@ -219,8 +345,8 @@ func (tm *RecipeManager) All(ctx context.Context, filter map[string]interface{},
 	for _, raw := range cur.Data {
 		var recipe *model.Recipe
 		if marshallErr := bson.Unmarshal(raw, &recipe); marshallErr == nil {
-			recipe.Pagination = *cur
-			recipes = append(recipes, recipe)
+			recipe.Pagination = pageOf(cur)
+			recipes = append(recipes, ordered(recipe))
 		}
 	}
 	if len(recipes) == 0 {
@@ -231,14 +357,14 @@ func (tm *RecipeManager) All(ctx context.Context, filter map[string]interface{},
 }
 
 func (tm *RecipeManager) Search(ctx context.Context, query string, limit int, page int) ([]*model.Recipe, error) {
-	l, cancel := context.WithTimeout(ctx, 1000*time.Millisecond)
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Query)
 	defer cancel()
 
+	// $text has to be the first stage of the pipeline
 	matchStage := bson.M{"$match": bson.M{"$text": bson.M{"$search": query}}}
-	lookupStage := bson.M{"$lookup": bson.M{"from": "ingredients", "localField": "_id", "foreignField": "recipe_id", "as": "ingredients"}}
 
 	var recipes []*model.Recipe
-	cur, err := pager.New(tm.Col).Context(l).Limit(int64(limit)).Page(int64(page)).Aggregate(lookupStage, matchStage)
+	cur, err := pager.New(tm.Col).Context(l).Limit(int64(limit)).Page(int64(page)).Aggregate(matchStage)
 
 	if err != nil {
 		return nil, err
@@ -246,7 +372,7 @@ func (tm *RecipeManager) Search(ctx context.Context, query string, limit int, pa
 	for _, raw := range cur.Data {
 		var recipe *model.Recipe
 		if marshallErr := bson.Unmarshal(raw, &recipe); marshallErr == nil {
-			recipe.Pagination = *cur
+			recipe.Pagination = pageOf(cur)
 			recipes = append(recipes, recipe)
 		}
 	}
@@ -256,3 +382,160 @@ func (tm *RecipeManager) Search(ctx context.Context, query string, limit int, pa
 	}
 	return recipes, nil
 }
+
+func (tm *RecipeManager) AllAfter(ctx context.Context, filter *model.RecipeFilter, fields []string, first int, after *primitive.ObjectID) ([]*model.Recipe, bool, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.List)
+	defer cancel()
+
+	query, err := tm.recipeQuery(l, filter)
+	if err != nil {
+		return nil, false, err
+	}
+	return tm.after(l, query, fields, first, after)
+}
+
+func (tm *RecipeManager) SearchAfter(ctx context.Context, query string, first int, after *primitive.ObjectID) ([]*model.Recipe, bool, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Query)
+	defer cancel()
+
+	return tm.after(l, bson.M{"$text": bson.M{"$search": query}}, nil, first, after)
+}
+
+// after runs query from the keyset position after, fetching one extra
+// document to learn whether another page exists. Seeking on the _id index
+// keeps deep pages as cheap as the first one.
+func (tm *RecipeManager) after(ctx context.Context, query bson.M, fields []string, first int, after *primitive.ObjectID) ([]*model.Recipe, bool, error) {
+	if after != nil {
+		query = bson.M{"$and": bson.A{query, bson.M{"_id": bson.M{"$gt": *after}}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{"$match", query}},
+		{{"$sort", bson.M{"_id": 1}}},
+		{{"$limit", first + 1}},
+	}
+	pipeline = append(pipeline, recipeStages(fields)...)
+	cur, err := tm.Col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var recipes []*model.Recipe
+	if err := cur.All(ctx, &recipes); err != nil {
+		return nil, false, err
+	}
+	for _, r := range recipes {
+		ordered(r)
+	}
+	if len(recipes) > first {
+		return recipes[:first], true, nil
+	}
+	return recipes, false, nil
+}
+
+func (tm *RecipeManager) ByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.Recipe, error) {
+	l, cancel := context.WithTimeout(ctx, tm.Timeouts.Query)
+	defer cancel()
+
+	cur, err := tm.Col.Find(l, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var recipes []*model.Recipe
+	if err := cur.All(l, &recipes); err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+// ordered puts the ingredients a lookup joined to r in recipe order.
+// Recipes read without the lookup keep nil ingredients, which the GraphQL
+// layer loads on demand.
+func ordered(r *model.Recipe) *model.Recipe {
+	if r.Ingredients != nil {
+		r.Ingredients = model.OrderIngredients(r.IngredientIDs, r.Ingredients)
+	}
+	return r
+}
+
+// newRecipe builds a recipe and its ingredient lines from args, with fresh
+// IDs and written by the user with the ID by, ready to be inserted.
+func newRecipe(args *model.NewRecipe, by *primitive.ObjectID) *model.Recipe {
+	slug := text.Slugify(args.Name)
+	originalURL := args.OriginalURL
+	recipe := &model.Recipe{
+		ID:            primitive.NewObjectID(),
+		Name:          args.Name,
+		Slug:          &slug,
+		ImageURL:      args.ImageURL,
+		OriginalURL:   &originalURL,
+		Servings:      args.Servings,
+		Yield:         args.Yield,
+		Ingredients:   []*model.Ingredient{},
+		IngredientIDs: []primitive.ObjectID{},
+		CreatedBy:     by,
+		UpdatedBy:     by,
+	}
+	recipe.SetTimers(model.NewTimers(args.Timers, args.Timings), model.Untimed(args.Timers))
+	recipe.Groups = args.GroupNames()
+	for _, line := range args.Lines() {
+		i := line.Ingredient
+		ingredient := newIngredient(primitive.NewObjectID(), recipe.ID, i.Name, i.Type, i.Quantity)
+		ingredient.Group = line.Group
+		ingredient.CreatedBy, ingredient.UpdatedBy = by, by
+		recipe.Ingredients = append(recipe.Ingredients, ingredient)
+		recipe.IngredientIDs = append(recipe.IngredientIDs, ingredient.ID)
+	}
+	recipe.SetSteps(model.NewSteps(args.Steps, args.Instructions, recipe.IngredientIDs))
+	return recipe
+}
+
+func newIngredient(id, recipeID primitive.ObjectID, name, kind, qty string) *model.Ingredient {
+	slug := text.Slugify(name)
+	measure := quantity.Parse(qty)
+	return &model.Ingredient{
+		ID:       id,
+		Name:     name,
+		Slug:     &slug,
+		Type:     kind,
+		Quantity: qty,
+		Measure:  &measure,
+		RecipeID: recipeID,
+	}
+}
+
+// recipeDocument is what the recipes collection stores for r: everything
+// but the ingredient lines themselves.
+func recipeDocument(r *model.Recipe) bson.M {
+	return bson.M{
+		"_id":               r.ID,
+		"name":              r.Name,
+		"slug":              r.Slug,
+		"timings":           r.Timings,
+		"timers":            r.Timers,
+		"prep_time":         r.PrepTime,
+		"cook_time":         r.CookTime,
+		"total_time":        r.TotalTime,
+		"instructions":      r.Instructions,
+		"imageURL":          r.ImageURL,
+		"originalURL":       r.OriginalURL,
+		"servings":          r.Servings,
+		"yield":             r.Yield,
+		"ingredient_ids":    r.IngredientIDs,
+		"ingredient_groups": r.Groups,
+		"created_by":        r.CreatedBy,
+		"updated_by":        r.UpdatedBy,
+	}
+}
+
+// pageOf converts the page metadata mongo-go-pagination computed.
+func pageOf(cur *pager.PaginatedData) model.Page {
+	p := cur.Pagination
+	return model.Page{
+		Total:     int(p.Total),
+		Page:      int(p.Page),
+		PerPage:   int(p.PerPage),
+		Prev:      int(p.Prev),
+		Next:      int(p.Next),
+		TotalPage: int(p.TotalPage),
+	}
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ShoppingList interface {
+	// Create saves list, which the GraphQL layer builds, under a fresh ID
+	// and as the list of the user ctx is signed in as.
+	Create(ctx context.Context, list *model.ShoppingList) (*model.ShoppingList, error)
+	// Check marks an item of a list as bought, or not.
+	Check(ctx context.Context, args *model.CheckShoppingItem) (*model.ShoppingList, error)
+	Delete(ctx context.Context, id string) (*model.ShoppingList, error)
+
+	Get(ctx context.Context, id string) (*model.ShoppingList, error)
+	// All returns the lists of owner, newest first.
+	All(ctx context.Context, owner primitive.ObjectID, limit int, page int) ([]*model.ShoppingList, error)
+}
+
+type ShoppingListManager struct {
+	Col      *mongo.Collection
+	Timeouts Timeouts
+}
+
+func NewShoppingListManager(d *mongo.Database, t Timeouts) *ShoppingListManager {
+	lists := d.Collection("shopping_lists")
+	return &ShoppingListManager{Col: lists, Timeouts: t}
+}
+
+func (sm *ShoppingListManager) Create(ctx context.Context, list *model.ShoppingList) (*model.ShoppingList, error) {
+	l, cancel := context.WithTimeout(ctx, sm.Timeouts.Write)
+	defer cancel()
+
+	created := *list
+	created.ID = primitive.NewObjectID()
+	created.CreatedBy = auth.UserID(ctx)
+	if _, err := sm.Col.InsertOne(l, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (sm *ShoppingListManager) Check(ctx context.Context, args *model.CheckShoppingItem) (*model.ShoppingList, error) {
+	l, cancel := context.WithTimeout(ctx, sm.Timeouts.Write)
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(args.ListID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "listID"}, "invalid id %q", args.ListID)
+	}
+	item, err := primitive.ObjectIDFromHex(args.ItemID)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"input", "itemID"}, "invalid id %q", args.ItemID)
+	}
+
+	var updated model.ShoppingList
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	query := bson.M{"_id": id, "items._id": item}
+	update := bson.M{"$set": bson.M{"items.$.checked": args.Checked}}
+	if err := sm.Col.FindOneAndUpdate(l, query, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (sm *ShoppingListManager) Delete(ctx context.Context, id string) (*model.ShoppingList, error) {
+	l, cancel := context.WithTimeout(ctx, sm.Timeouts.Write)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var deleted model.ShoppingList
+	if err := sm.Col.FindOneAndDelete(l, bson.M{"_id": oid}).Decode(&deleted); err != nil {
+		return nil, err
+	}
+	return &deleted, nil
+}
+
+func (sm *ShoppingListManager) Get(ctx context.Context, id string) (*model.ShoppingList, error) {
+	l, cancel := context.WithTimeout(ctx, sm.Timeouts.Read)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var list model.ShoppingList
+	if err := sm.Col.FindOne(l, bson.M{"_id": oid}).Decode(&list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (sm *ShoppingListManager) All(ctx context.Context, owner primitive.ObjectID, limit int, page int) ([]*model.ShoppingList, error) {
+	l, cancel := context.WithTimeout(ctx, sm.Timeouts.List)
+	defer cancel()
+
+	if page < 1 {
+		page = 1
+	}
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	cur, err := sm.Col.Find(l, bson.M{"created_by": owner}, opts)
+	if err != nil {
+		return nil, err
+	}
+	lists := []*model.ShoppingList{}
+	if err := cur.All(l, &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
@@ -0,0 +1,43 @@
+package db_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ottolauncher/recipes/graph/db/dbtest"
+	db "github.com/ottolauncher/recipes/graph/db/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestStore needs a MongoDB replica set, since recipes are written in
+// transactions, and its URI in MONGO_TEST_URI. Each test gets a database of
+// its own, dropped once it is done.
+func TestStore(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+	client := db.Init(uri, 10*time.Second)
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	timeouts := db.Timeouts{Write: time.Second, Read: time.Second, Query: time.Second, List: 2 * time.Second}
+	dbtest.Run(t, func(t *testing.T) *dbtest.Backend {
+		d := client.Database("recipes_test_" + primitive.NewObjectID().Hex())
+		t.Cleanup(func() { d.Drop(context.Background()) })
+		if err := db.EnsureIndexes(context.Background(), d); err != nil {
+			t.Fatal(err)
+		}
+		return &dbtest.Backend{
+			Recipes:     db.NewRecipeManager(d, timeouts),
+			Ingredients: db.NewIngredientManager(d, timeouts),
+			Foods:       db.NewFoodManager(d, timeouts),
+			Lists:       db.NewShoppingListManager(d, timeouts),
+			Pantries:    db.NewPantryManager(d, timeouts),
+			Plans:       db.NewMealPlanManager(d, timeouts),
+			Users:       db.NewUserManager(d, timeouts),
+			Keys:        db.NewAPIKeyManager(d, timeouts),
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// transaction runs fn inside a multi-document transaction, so writes that
+// span the recipes and ingredients collections either all commit or all
+// roll back. fn must do its work through the session context it is given,
+// and may run more than once when the server reports a transient error.
+// The transaction, retries included, ends when ctx does.
+//
+// Transactions need MongoDB to run as a replica set; a single-node replica
+// set is enough for development.
+func transaction(ctx context.Context, client *mongo.Client, fn func(sc mongo.SessionContext) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type User interface {
+	// Create saves user, whose password the caller has hashed, under a
+	// fresh ID. It fails with a conflict when the email is taken.
+	Create(ctx context.Context, user *model.User) (*model.User, error)
+
+	// SetRole changes the role of the user with the given ID.
+	SetRole(ctx context.Context, id string, role model.Role) (*model.User, error)
+
+	Get(ctx context.Context, id string) (*model.User, error)
+	ByEmail(ctx context.Context, email string) (*model.User, error)
+	// ByIDs returns the users with the given IDs in one round-trip. Unknown
+	// IDs are left out.
+	ByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.User, error)
+}
+
+// email carries a unique index, which EnsureIndexes creates, so two
+// registrations at once cannot take the same address.
+type UserManager struct {
+	Col      *mongo.Collection
+	Timeouts Timeouts
+}
+
+func NewUserManager(d *mongo.Database, t Timeouts) *UserManager {
+	users := d.Collection("users")
+	return &UserManager{Col: users, Timeouts: t}
+}
+
+func (um *UserManager) Create(ctx context.Context, user *model.User) (*model.User, error) {
+	l, cancel := context.WithTimeout(ctx, um.Timeouts.Write)
+	defer cancel()
+
+	created := *user
+	created.ID = primitive.NewObjectID()
+	created.Email = model.NormalizeEmail(user.Email)
+	if _, err := um.Col.InsertOne(l, &created); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, emailTaken(created.Email)
+		}
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (um *UserManager) SetRole(ctx context.Context, id string, role model.Role) (*model.User, error) {
+	l, cancel := context.WithTimeout(ctx, um.Timeouts.Write)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var updated model.User
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := um.Col.FindOneAndUpdate(l, bson.M{"_id": oid}, bson.M{"$set": bson.M{"role": role}}, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (um *UserManager) Get(ctx context.Context, id string) (*model.User, error) {
+	l, cancel := context.WithTimeout(ctx, um.Timeouts.Read)
+	defer cancel()
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	var user model.User
+	if err := um.Col.FindOne(l, bson.M{"_id": oid}).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (um *UserManager) ByEmail(ctx context.Context, email string) (*model.User, error) {
+	l, cancel := context.WithTimeout(ctx, um.Timeouts.Read)
+	defer cancel()
+
+	var user model.User
+	if err := um.Col.FindOne(l, bson.M{"email": model.NormalizeEmail(email)}).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (um *UserManager) ByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*model.User, error) {
+	l, cancel := context.WithTimeout(ctx, um.Timeouts.Query)
+	defer cancel()
+
+	cur, err := um.Col.Find(l, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var users []*model.User
+	if err := cur.All(l, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func emailTaken(email string) error {
+	return &apperr.Error{Code: apperr.Conflict, Message: email + " is already registered", Field: []string{"input", "email"}}
+}
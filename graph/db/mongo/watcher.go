@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Publisher is the part of a subscription broker the watcher needs.
+type Publisher interface {
+	Publish(ctx context.Context, ev *model.RecipeEvent) error
+}
+
+// Watcher tails the recipes and ingredients change streams and turns every
+// change, whoever wrote it, into the events the recipe subscription
+// delivers. Change streams need MongoDB to run as a replica set.
+type Watcher struct {
+	rm     *RecipeManager
+	im     *IngredientManager
+	tokens *mongo.Collection
+	pub    Publisher
+}
+
+// changeEvent is the subset of a change stream document the watcher reads.
+type changeEvent struct {
+	ID            bson.Raw `bson:"_id"`
+	OperationType string   `bson:"operationType"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument             bson.Raw `bson:"fullDocument"`
+	FullDocumentBeforeChange bson.Raw `bson:"fullDocumentBeforeChange"`
+}
+
+// resumeToken is stored in the resume_tokens collection, one document per
+// watched collection, so a restarted server continues where it stopped.
+type resumeToken struct {
+	ID    string   `bson:"_id"`
+	Token bson.Raw `bson:"token"`
+}
+
+func NewWatcher(rm *RecipeManager, im *IngredientManager, pub Publisher) *Watcher {
+	return &Watcher{
+		rm:     rm,
+		im:     im,
+		tokens: rm.DB.Collection("resume_tokens"),
+		pub:    pub,
+	}
+}
+
+// Run watches both collections until ctx is done. Stream failures are logged
+// and the stream is reopened from the last saved token.
+func (w *Watcher) Run(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		w.watch(ctx, w.im.Col, true, w.ingredientChanged)
+		close(done)
+	}()
+	w.watch(ctx, w.rm.Col, false, w.recipeChanged)
+	<-done
+}
+
+func (w *Watcher) watch(ctx context.Context, col *mongo.Collection, preImages bool, handle func(context.Context, *changeEvent) error) {
+	for ctx.Err() == nil {
+		if err := w.stream(ctx, col, preImages, handle); err != nil && ctx.Err() == nil {
+			log.Printf("watch %s: %v, retrying", col.Name(), err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+// stream reads one change stream until it fails. With preImages, delete
+// events carry the document as it was before, when the collection keeps
+// pre-images.
+func (w *Watcher) stream(ctx context.Context, col *mongo.Collection, preImages bool, handle func(context.Context, *changeEvent) error) error {
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if preImages {
+		opts.SetFullDocumentBeforeChange(options.WhenAvailable)
+	}
+
+	var saved resumeToken
+	err := w.tokens.FindOne(ctx, bson.M{"_id": col.Name()}).Decode(&saved)
+	switch {
+	case err == nil:
+		opts.SetResumeAfter(saved.Token)
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return err
+	}
+
+	cs, err := col.Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil && saved.Token != nil {
+		// the token may have fallen off the oplog; start from now instead
+		// of retrying a resume that can never succeed
+		log.Printf("watch %s: cannot resume (%v), starting from now", col.Name(), err)
+		if _, err := w.tokens.DeleteOne(ctx, bson.M{"_id": col.Name()}); err != nil {
+			return err
+		}
+		cs, err = col.Watch(ctx, mongo.Pipeline{}, opts.SetResumeAfter(nil))
+	}
+	if err != nil {
+		return err
+	}
+	defer cs.Close(context.Background())
+
+	for cs.Next(ctx) {
+		var ev changeEvent
+		if err := cs.Decode(&ev); err != nil {
+			return err
+		}
+		if ev.OperationType == "invalidate" {
+			if _, err := w.tokens.DeleteOne(ctx, bson.M{"_id": col.Name()}); err != nil {
+				return fmt.Errorf("change stream invalidated, dropping its token: %w", err)
+			}
+			return errors.New("change stream invalidated")
+		}
+		if err := handle(ctx, &ev); err != nil {
+			log.Printf("watch %s: %v", col.Name(), err)
+		}
+		_, err := w.tokens.UpdateOne(ctx,
+			bson.M{"_id": col.Name()},
+			bson.M{"$set": bson.M{"token": cs.ResumeToken()}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return cs.Err()
+}
+
+func (w *Watcher) recipeChanged(ctx context.Context, ev *changeEvent) error {
+	var kind model.RecipeEventType
+	switch ev.OperationType {
+	case "insert":
+		kind = model.RecipeEventTypeCreated
+	case "update", "replace":
+		kind = model.RecipeEventTypeUpdated
+	case "delete":
+		// only the key survives a delete
+		return w.pub.Publish(ctx, &model.RecipeEvent{
+			Type:   model.RecipeEventTypeDeleted,
+			Recipe: &model.Recipe{ID: ev.DocumentKey.ID},
+		})
+	default:
+		return nil
+	}
+	if ev.FullDocument == nil {
+		// updated then deleted before the lookup ran
+		return nil
+	}
+
+	// the full document lacks the ingredient lines, so read the recipe the
+	// way queries do
+	id := ev.DocumentKey.ID.Hex()
+	recipe, err := w.rm.Get(ctx, &model.RecipeFilter{ID: &id}, nil)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+	return w.pub.Publish(ctx, &model.RecipeEvent{Type: kind, Recipe: recipe})
+}
+
+// ingredientChanged reports a change to an ingredient document as an update
+// of the recipe it belongs to. Deleted ingredients are found in the
+// pre-image of the event; without one the delete is left to the update of
+// the recipe it was unlinked from. Standalone ingredients have no recipe
+// and are skipped.
+func (w *Watcher) ingredientChanged(ctx context.Context, ev *changeEvent) error {
+	doc := ev.FullDocument
+	if ev.OperationType == "delete" {
+		doc = ev.FullDocumentBeforeChange
+	}
+	if doc == nil {
+		return nil
+	}
+	var ingredient model.Ingredient
+	if err := bson.Unmarshal(doc, &ingredient); err != nil {
+		return err
+	}
+	if ingredient.RecipeID.IsZero() {
+		return nil
+	}
+
+	id := ingredient.RecipeID.Hex()
+	recipe, err := w.rm.Get(ctx, &model.RecipeFilter{ID: &id}, nil)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+	return w.pub.Publish(ctx, &model.RecipeEvent{Type: model.RecipeEventTypeUpdated, Recipe: recipe})
+}
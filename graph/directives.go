@@ -0,0 +1,194 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/generated"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Directives returns the handlers of the directives the schema declares.
+func (r *Resolver) Directives() generated.DirectiveRoot {
+	return generated.DirectiveRoot{Auth: r.authDirective, Owner: r.ownerDirective, Scope: r.scopeDirective}
+}
+
+// authDirective handles @auth: it lets through signed in users holding the
+// role the field requires, and admins.
+func (r *Resolver) authDirective(ctx context.Context, obj interface{}, next graphql.Resolver, requires model.Role) (interface{}, error) {
+	u := auth.ForContext(ctx)
+	if u == nil {
+		return nil, apperr.New(apperr.Unauthenticated, "sign in first")
+	}
+	if requires == model.RoleAdmin && r.role(u) != model.RoleAdmin {
+		return nil, apperr.New(apperr.Forbidden, "only admins may do this")
+	}
+	return next(ctx)
+}
+
+// scopeDirective handles @scope: requests made with an API key need the
+// scope the field requires. Others go through, left to the other
+// directives of the field.
+func (r *Resolver) scopeDirective(ctx context.Context, obj interface{}, next graphql.Resolver, requires string) (interface{}, error) {
+	if k := auth.KeyForContext(ctx); k != nil && !k.Allows(requires) {
+		return nil, apperr.New(apperr.Forbidden, "the API key lacks the %s scope", requires)
+	}
+	return next(ctx)
+}
+
+// ownerDirective handles @owner: it lets through admins and the author of
+// what the field reads or changes. Whatever was written anonymously has no
+// author, so only admins may touch it. Requests made with an API key alone
+// act for the user who issued the key, but never as an admin. Errors
+// finding the author, such as an unknown ID, are returned as they are.
+func (r *Resolver) ownerDirective(ctx context.Context, obj interface{}, next graphql.Resolver) (interface{}, error) {
+	u := auth.ForContext(ctx)
+	if u == nil && auth.KeyForContext(ctx) == nil {
+		return nil, apperr.New(apperr.Unauthenticated, "sign in first")
+	}
+	if u != nil && r.role(u) == model.RoleAdmin {
+		return next(ctx)
+	}
+	author, err := r.author(ctx, graphql.GetFieldContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if id := auth.UserID(ctx); author == nil || id == nil || *author != *id {
+		return nil, apperr.New(apperr.Forbidden, "only the author or an admin may change this")
+	}
+	return next(ctx)
+}
+
+// author returns who wrote what the @owner field of fc reads or changes,
+// nil when it was written anonymously. Recipes locked by a moderator, and
+// their lines, cannot be changed by their author either. Deletes by filter
+// are narrowed to the ID found, so that by the time the resolver runs the
+// filter cannot match something else.
+func (r *Resolver) author(ctx context.Context, fc *graphql.FieldContext) (*primitive.ObjectID, error) {
+	fields := []string{"createdBy", "locked"}
+	switch fc.Field.Name {
+	case "updateRecipe":
+		in := fc.Args["input"].(model.UpdateRecipe)
+		recipe, err := r.RM.Get(ctx, &model.RecipeFilter{ID: &in.ID}, fields)
+		if err != nil {
+			return nil, err
+		}
+		return recipe.CreatedBy, unlocked(recipe)
+	case "deleteRecipe":
+		filter := fc.Args["filter"].(model.RecipeFilter)
+		recipe, err := r.RM.Get(ctx, &filter, fields)
+		if err != nil {
+			return nil, err
+		}
+		id := recipe.ID.Hex()
+		filter.ID = &id
+		fc.Args["filter"] = filter
+		return recipe.CreatedBy, unlocked(recipe)
+	case "updateIngredient":
+		in, _ := fc.Args["input"].(*model.UpdateIngredient)
+		if in == nil {
+			return nil, apperr.Invalid([]string{"input"}, "input is required")
+		}
+		ingredient, err := r.IM.Get(ctx, &model.IngredientFilter{ID: &in.ID}, []string{"createdBy", "recipeID"})
+		if err != nil {
+			return nil, err
+		}
+		return ingredient.CreatedBy, r.recipeUnlocked(ctx, ingredient)
+	case "deleteIngredient":
+		filter := fc.Args["filter"].(model.IngredientFilter)
+		ingredient, err := r.IM.Get(ctx, &filter, []string{"createdBy", "recipeID"})
+		if err != nil {
+			return nil, err
+		}
+		id := ingredient.ID.Hex()
+		filter.ID = &id
+		fc.Args["filter"] = filter
+		return ingredient.CreatedBy, r.recipeUnlocked(ctx, ingredient)
+	case "updateFood":
+		in := fc.Args["input"].(model.UpdateFood)
+		id, err := primitive.ObjectIDFromHex(in.ID)
+		if err != nil {
+			return nil, apperr.Invalid([]string{"input", "id"}, "invalid ID %q", in.ID)
+		}
+		foods, err := r.FM.ByIDs(ctx, []primitive.ObjectID{id})
+		if err != nil {
+			return nil, err
+		}
+		if len(foods) == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		return foods[0].CreatedBy, nil
+	case "checkShoppingItem":
+		return r.listAuthor(ctx, fc.Args["input"].(model.CheckShoppingItem).ListID)
+	case "deleteShoppingList", "savedShoppingList":
+		return r.listAuthor(ctx, fc.Args["id"].(string))
+	case "updatePantry":
+		return r.pantryAuthor(ctx, fc.Args["input"].(model.UpdatePantry).ID)
+	case "deletePantry", "pantry":
+		return r.pantryAuthor(ctx, fc.Args["id"].(string))
+	case "cookableRecipes":
+		return r.pantryAuthor(ctx, fc.Args["pantryID"].(string))
+	case "planMeal":
+		return r.planAuthor(ctx, fc.Args["input"].(model.PlanMeal).PlanID)
+	case "moveMeal":
+		return r.planAuthor(ctx, fc.Args["input"].(model.MoveMeal).PlanID)
+	case "createShoppingListFromPlan":
+		return r.planAuthor(ctx, fc.Args["input"].(model.MealPlanShoppingList).PlanID)
+	case "removeMeal", "resetCalendarFeed":
+		return r.planAuthor(ctx, fc.Args["planID"].(string))
+	case "deleteMealPlan", "mealPlan":
+		return r.planAuthor(ctx, fc.Args["id"].(string))
+	}
+	return nil, fmt.Errorf("@owner does not know what %s changes", fc.Field.Name)
+}
+
+func (r *Resolver) listAuthor(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	list, err := r.SM.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return list.CreatedBy, nil
+}
+
+func (r *Resolver) pantryAuthor(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	pantry, err := r.PM.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return pantry.CreatedBy, nil
+}
+
+func (r *Resolver) planAuthor(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	plan, err := r.MP.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return plan.CreatedBy, nil
+}
+
+// unlocked fails when a moderator locked recipe.
+func unlocked(recipe *model.Recipe) error {
+	if recipe.Locked {
+		return apperr.New(apperr.Forbidden, "the recipe is locked by a moderator")
+	}
+	return nil
+}
+
+// recipeUnlocked fails when the recipe ingredient belongs to was locked by a
+// moderator. Lines of no recipe are never locked.
+func (r *Resolver) recipeUnlocked(ctx context.Context, ingredient *model.Ingredient) error {
+	if ingredient.RecipeID.IsZero() {
+		return nil
+	}
+	id := ingredient.RecipeID.Hex()
+	recipe, err := r.RM.Get(ctx, &model.RecipeFilter{ID: &id}, []string{"locked"})
+	if err != nil {
+		return err
+	}
+	return unlocked(recipe)
+}
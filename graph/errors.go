@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorPresenter sets the "code" extension of every error sent to clients.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	e := apperr.From(err)
+	var gerr *gqlerror.Error
+	if e.Code == apperr.Internal && errors.As(err, &gerr) {
+		// gqlgen's own errors, such as arguments that fail to coerce
+		e = &apperr.Error{Code: apperr.Validation, Message: gerr.Message, Err: err}
+	}
+
+	gerr = graphql.DefaultErrorPresenter(ctx, err)
+	gerr.Message = e.Message
+	if gerr.Extensions == nil {
+		gerr.Extensions = map[string]interface{}{}
+	}
+	// keep the codes gqlgen sets itself, such as GRAPHQL_VALIDATION_FAILED
+	if _, ok := gerr.Extensions["code"]; !ok {
+		gerr.Extensions["code"] = e.Code
+	}
+	if len(e.Field) > 0 {
+		gerr.Extensions["field"] = e.Field
+	}
+	return gerr
+}
+
+// report turns expected failures (not found, invalid input, conflicts...)
+// into payload errors and also adds them, with the same code, to the
+// response errors. An unexpected error is returned as is so the mutation
+// fails as a whole.
+func report(ctx context.Context, errs ...error) ([]*model.UserError, error) {
+	out := []*model.UserError{}
+	for _, err := range errs {
+		e := apperr.From(err)
+		if e.Code == apperr.Internal {
+			return nil, err
+		}
+		graphql.AddError(ctx, e)
+		out = append(out, &model.UserError{
+			Code:    model.ErrorCode(e.Code),
+			Message: e.Message,
+			Field:   e.Field,
+		})
+	}
+	return out, nil
+}
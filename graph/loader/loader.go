@@ -0,0 +1,142 @@
+// Package loader batches and caches the lookups nested GraphQL selections
+// make, so resolving a field across a whole list costs one database
+// round-trip instead of one per item.
+package loader
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/graph-gophers/dataloader/v7"
+	db "github.com/ottolauncher/recipes/graph/db/mongo"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// wait is how long a loader collects keys before it runs a batch. Sibling
+// fields are resolved concurrently, so a short wait is enough to catch a
+// whole list.
+const wait = 2 * time.Millisecond
+
+// Loaders holds the loaders of one GraphQL operation.
+type Loaders struct {
+	// IngredientsByRecipe loads the ingredient lines of a recipe, in no
+	// particular order.
+	IngredientsByRecipe *dataloader.Loader[primitive.ObjectID, []*model.Ingredient]
+	// RecipeByID loads a recipe without its ingredients; unknown IDs load
+	// as nil.
+	RecipeByID *dataloader.Loader[primitive.ObjectID, *model.Recipe]
+	// FoodByID loads a catalog entry; unknown IDs load as nil.
+	FoodByID *dataloader.Loader[primitive.ObjectID, *model.Food]
+	// UserByID loads a user; unknown IDs load as nil.
+	UserByID *dataloader.Loader[primitive.ObjectID, *model.User]
+}
+
+type ctxKey struct{}
+
+// New returns loaders reading from rm, im, fm and um. Loaded values are
+// cached unless cache is false.
+func New(rm db.IRecipe, im db.Ingredient, fm db.Food, um db.User, cache bool) *Loaders {
+	ingredientOpts := []dataloader.Option[primitive.ObjectID, []*model.Ingredient]{dataloader.WithWait[primitive.ObjectID, []*model.Ingredient](wait)}
+	recipeOpts := []dataloader.Option[primitive.ObjectID, *model.Recipe]{dataloader.WithWait[primitive.ObjectID, *model.Recipe](wait)}
+	foodOpts := []dataloader.Option[primitive.ObjectID, *model.Food]{dataloader.WithWait[primitive.ObjectID, *model.Food](wait)}
+	userOpts := []dataloader.Option[primitive.ObjectID, *model.User]{dataloader.WithWait[primitive.ObjectID, *model.User](wait)}
+	if !cache {
+		ingredientOpts = append(ingredientOpts, dataloader.WithCache[primitive.ObjectID, []*model.Ingredient](&dataloader.NoCache[primitive.ObjectID, []*model.Ingredient]{}))
+		recipeOpts = append(recipeOpts, dataloader.WithCache[primitive.ObjectID, *model.Recipe](&dataloader.NoCache[primitive.ObjectID, *model.Recipe]{}))
+		foodOpts = append(foodOpts, dataloader.WithCache[primitive.ObjectID, *model.Food](&dataloader.NoCache[primitive.ObjectID, *model.Food]{}))
+		userOpts = append(userOpts, dataloader.WithCache[primitive.ObjectID, *model.User](&dataloader.NoCache[primitive.ObjectID, *model.User]{}))
+	}
+
+	return &Loaders{
+		IngredientsByRecipe: dataloader.NewBatchedLoader(ingredientsByRecipe(im), ingredientOpts...),
+		RecipeByID:          dataloader.NewBatchedLoader(recipeByID(rm), recipeOpts...),
+		FoodByID:            dataloader.NewBatchedLoader(foodByID(fm), foodOpts...),
+		UserByID:            dataloader.NewBatchedLoader(userByID(um), userOpts...),
+	}
+}
+
+// Middleware gives every GraphQL operation loaders of its own. A
+// subscription lives as long as its client, so its loaders batch but do not
+// cache, and every event sees fresh data.
+func Middleware(rm db.IRecipe, im db.Ingredient, fm db.Food, um db.User) graphql.OperationMiddleware {
+	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		cache := graphql.GetOperationContext(ctx).Operation.Operation != ast.Subscription
+		return next(context.WithValue(ctx, ctxKey{}, New(rm, im, fm, um, cache)))
+	}
+}
+
+// For returns the loaders of the operation ctx belongs to.
+func For(ctx context.Context) *Loaders {
+	return ctx.Value(ctxKey{}).(*Loaders)
+}
+
+func ingredientsByRecipe(im db.Ingredient) dataloader.BatchFunc[primitive.ObjectID, []*model.Ingredient] {
+	return func(ctx context.Context, keys []primitive.ObjectID) []*dataloader.Result[[]*model.Ingredient] {
+		ingredients, err := im.ByRecipes(ctx, keys)
+
+		byRecipe := make(map[primitive.ObjectID][]*model.Ingredient, len(keys))
+		for _, i := range ingredients {
+			byRecipe[i.RecipeID] = append(byRecipe[i.RecipeID], i)
+		}
+		results := make([]*dataloader.Result[[]*model.Ingredient], len(keys))
+		for n, id := range keys {
+			found := byRecipe[id]
+			if found == nil {
+				found = []*model.Ingredient{}
+			}
+			results[n] = &dataloader.Result[[]*model.Ingredient]{Data: found, Error: err}
+		}
+		return results
+	}
+}
+
+func recipeByID(rm db.IRecipe) dataloader.BatchFunc[primitive.ObjectID, *model.Recipe] {
+	return func(ctx context.Context, keys []primitive.ObjectID) []*dataloader.Result[*model.Recipe] {
+		recipes, err := rm.ByIDs(ctx, keys)
+
+		byID := make(map[primitive.ObjectID]*model.Recipe, len(recipes))
+		for _, r := range recipes {
+			byID[r.ID] = r
+		}
+		results := make([]*dataloader.Result[*model.Recipe], len(keys))
+		for n, id := range keys {
+			results[n] = &dataloader.Result[*model.Recipe]{Data: byID[id], Error: err}
+		}
+		return results
+	}
+}
+
+func foodByID(fm db.Food) dataloader.BatchFunc[primitive.ObjectID, *model.Food] {
+	return func(ctx context.Context, keys []primitive.ObjectID) []*dataloader.Result[*model.Food] {
+		foods, err := fm.ByIDs(ctx, keys)
+
+		byID := make(map[primitive.ObjectID]*model.Food, len(foods))
+		for _, f := range foods {
+			byID[f.ID] = f
+		}
+		results := make([]*dataloader.Result[*model.Food], len(keys))
+		for n, id := range keys {
+			results[n] = &dataloader.Result[*model.Food]{Data: byID[id], Error: err}
+		}
+		return results
+	}
+}
+
+func userByID(um db.User) dataloader.BatchFunc[primitive.ObjectID, *model.User] {
+	return func(ctx context.Context, keys []primitive.ObjectID) []*dataloader.Result[*model.User] {
+		users, err := um.ByIDs(ctx, keys)
+
+		byID := make(map[primitive.ObjectID]*model.User, len(users))
+		for _, u := range users {
+			byID[u.ID] = u
+		}
+		results := make([]*dataloader.Result[*model.User], len(keys))
+		for n, id := range keys {
+			results[n] = &dataloader.Result[*model.User]{Data: byID[id], Error: err}
+		}
+		return results
+	}
+}
@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/ical"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// mealTimes is when the meals of each slot start, in hours and minutes.
+var mealTimes = map[model.MealSlot][2]int{
+	model.MealSlotBreakfast: {8, 0},
+	model.MealSlotLunch:     {12, 30},
+	model.MealSlotDinner:    {19, 0},
+}
+
+// CalendarPath is where the iCalendar feed of the plan with the given ID
+// and feed token is served. Calendar applications cannot sign in, so the
+// token is what keeps others from reading the plan.
+func CalendarPath(id string, token string) string {
+	return "/plans/" + id + "/" + token + "/calendar.ics"
+}
+
+// newFeedToken returns a fresh token for the calendar feed of a plan.
+func newFeedToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// WriteCalendar writes the meals of the plan with the given ID to w as an
+// iCalendar feed, provided token is the feed token of the plan; otherwise
+// the plan is reported not found. Each meal starts at the time of its slot
+// and lasts as long as the recipe takes, or an hour when the recipe is not
+// timed.
+func (r *Resolver) WriteCalendar(ctx context.Context, id string, token string, w io.Writer) error {
+	plan, err := r.MP.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if plan.FeedToken == "" || subtle.ConstantTimeCompare([]byte(plan.FeedToken), []byte(token)) != 1 {
+		return apperr.New(apperr.NotFound, "meal plan not found")
+	}
+	entries := plan.Between("", "")
+	recipes, err := r.plannedRecipes(ctx, entries)
+	if err != nil {
+		return err
+	}
+
+	events := make([]ical.Event, 0, len(entries))
+	for _, e := range entries {
+		day, err := time.Parse(model.DateLayout, e.Date)
+		if err != nil {
+			return err
+		}
+		clock := mealTimes[e.Slot]
+		start := day.Add(time.Duration(clock[0])*time.Hour + time.Duration(clock[1])*time.Minute)
+		event := ical.Event{
+			UID:     e.ID.Hex() + "@recipes",
+			Start:   start,
+			End:     start.Add(time.Hour),
+			Summary: string(e.Slot[:1]) + strings.ToLower(string(e.Slot[1:])),
+		}
+		if recipe := recipes[e.RecipeID]; recipe != nil {
+			event.Summary += ": " + recipe.Name
+			if recipe.TotalTime != nil && *recipe.TotalTime > 0 {
+				event.End = start.Add(time.Duration(*recipe.TotalTime) * time.Second)
+			}
+			if recipe.OriginalURL != nil {
+				event.URL = *recipe.OriginalURL
+			}
+		}
+		if e.Servings != nil {
+			event.Description = fmt.Sprintf("%d servings", *e.Servings)
+		}
+		events = append(events, event)
+	}
+	return ical.Write(w, plan.Name, events)
+}
+
+// planShoppingList builds the shopping list for the meals of a plan between
+// two days, each recipe scaled to the servings planned for it. Meals whose
+// recipe was deleted are left out.
+func (r *Resolver) planShoppingList(ctx context.Context, path []string, input *model.MealPlanShoppingList) (*model.ShoppingList, error) {
+	if errs := validateDateRange(path, input.From, input.To); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	plan, err := r.MP.Get(ctx, input.PlanID)
+	if err != nil {
+		e := *apperr.From(err)
+		if e.Code == apperr.Internal {
+			return nil, err
+		}
+		e.Field = at(path, "planID")
+		return nil, &e
+	}
+	entries := plan.Between(input.From, input.To)
+	recipes, err := r.plannedRecipes(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ids      []string
+		servings []*int
+	)
+	for _, e := range entries {
+		if recipes[e.RecipeID] != nil {
+			ids, servings = append(ids, e.RecipeID.Hex()), append(servings, e.Servings)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, apperr.Invalid(at(path, "from"), "no meals are planned from %s to %s", input.From, input.To)
+	}
+	list, err := r.shoppingList(ctx, path, ids, servings, input.Units)
+	if err != nil {
+		// the recipes come from the plan, not from the input
+		e := *apperr.From(err)
+		if e.Code == apperr.Internal {
+			return nil, err
+		}
+		e.Field = at(path, "planID")
+		return nil, &e
+	}
+	list.Name = input.Name
+	return list, nil
+}
+
+// plannedRecipes returns the recipes entries plan by ID. Recipes deleted
+// since they were planned are left out.
+func (r *Resolver) plannedRecipes(ctx context.Context, entries []*model.MealPlanEntry) (map[primitive.ObjectID]*model.Recipe, error) {
+	recipes := map[primitive.ObjectID]*model.Recipe{}
+	if len(entries) == 0 {
+		return recipes, nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(entries))
+	for _, e := range entries {
+		if _, ok := recipes[e.RecipeID]; !ok {
+			recipes[e.RecipeID] = nil
+			ids = append(ids, e.RecipeID)
+		}
+	}
+	found, err := r.RM.ByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, recipe := range found {
+		recipes[recipe.ID] = recipe
+	}
+	return recipes, nil
+}
+
+// checkRecipe reports when there is no recipe with the given ID, at path.
+func (r *Resolver) checkRecipe(ctx context.Context, path []string, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return apperr.Invalid(path, "invalid id %q", id)
+	}
+	found, err := r.RM.ByIDs(ctx, []primitive.ObjectID{oid})
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return &apperr.Error{Code: apperr.NotFound, Message: "recipe " + id + " not found", Field: path}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The scopes an API key can be granted.
+const (
+	ScopeRecipesRead  = "recipes:read"
+	ScopeRecipesWrite = "recipes:write"
+)
+
+var Scopes = []string{ScopeRecipesRead, ScopeRecipesWrite}
+
+// APIKey lets a partner application call the API without a user.
+type APIKey struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name   string             `json:"name"`
+	Scopes []string           `json:"scopes"`
+	// Hash is the SHA-256 of the secret part of the key, never sent out.
+	Hash      []byte              `json:"-" bson:"hash"`
+	CreatedBy *primitive.ObjectID `json:"createdBy" bson:"created_by,omitempty"`
+	// Requests counts the requests made with the key, the last of them at
+	// LastUsedAt.
+	Requests   int        `json:"requests"`
+	LastUsedAt *time.Time `json:"lastUsedAt" bson:"last_used_at,omitempty"`
+	RotatedAt  *time.Time `json:"rotatedAt" bson:"rotated_at,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt" bson:"revoked_at,omitempty"`
+}
+
+// Allows reports whether k was granted scope.
+func (k *APIKey) Allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/utils/duration"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecipeFilter and IngredientFilter are bound to the GraphQL inputs of the
+// same name. Raw is not part of the schema: resolvers only fill it from the
+// raw Map argument when the server allows raw filters. Nor is FoodIDs, which
+// resolvers set to find the recipes using any of the given foods.
+
+type RecipeFilter struct {
+	ID            *string                `json:"id"`
+	Name          *StringFilter          `json:"name"`
+	Slug          *StringFilter          `json:"slug"`
+	CreatedAt     *TimeRange             `json:"createdAt"`
+	PrepTime      *DurationFilter        `json:"prepTime"`
+	CookTime      *DurationFilter        `json:"cookTime"`
+	TotalTime     *DurationFilter        `json:"totalTime"`
+	HasIngredient *string                `json:"hasIngredient"`
+	FoodIDs       []primitive.ObjectID   `json:"-"`
+	Raw           map[string]interface{} `json:"-"`
+}
+
+type IngredientFilter struct {
+	ID        *string                `json:"id"`
+	Name      *StringFilter          `json:"name"`
+	Slug      *StringFilter          `json:"slug"`
+	Type      *StringFilter          `json:"type"`
+	RecipeID  *string                `json:"recipeID"`
+	CreatedAt *TimeRange             `json:"createdAt"`
+	Raw       map[string]interface{} `json:"-"`
+}
+
+// DurationBound is one bound of a DurationFilter: Op is "lt", "lte", "gt"
+// or "gte", and Seconds the duration it compares to.
+type DurationBound struct {
+	Op      string
+	Seconds int
+}
+
+// Bounds parses the bounds of f. A bound that is not a duration is a
+// validation error on its field below path.
+func (f *DurationFilter) Bounds(path []string) ([]DurationBound, error) {
+	if f == nil {
+		return nil, nil
+	}
+	var bounds []DurationBound
+	for _, b := range []struct {
+		op string
+		v  *string
+	}{{"lt", f.Lt}, {"lte", f.Lte}, {"gt", f.Gt}, {"gte", f.Gte}} {
+		if b.v == nil {
+			continue
+		}
+		d, err := duration.Parse(*b.v)
+		if err != nil {
+			return nil, apperr.Invalid(append(append([]string{}, path...), b.op), "%v", err)
+		}
+		bounds = append(bounds, DurationBound{Op: b.op, Seconds: int(d / time.Second)})
+	}
+	return bounds, nil
+}
+
+// Holds reports whether seconds, which is nil for recipes without the
+// time, is within b.
+func (b DurationBound) Holds(seconds *int) bool {
+	if seconds == nil {
+		return false
+	}
+	switch b.Op {
+	case "lt":
+		return *seconds < b.Seconds
+	case "lte":
+		return *seconds <= b.Seconds
+	case "gt":
+		return *seconds > b.Seconds
+	}
+	return *seconds >= b.Seconds
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/utils/text"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Food is an entry of the ingredient catalog: what an ingredient line such
+// as "2 cups flour" is made of, whatever the line calls it.
+type Food struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name     string             `json:"name"`
+	Slug     string             `json:"slug"`
+	Synonyms []string           `json:"synonyms" bson:"synonyms"`
+	Category *string            `json:"category,omitempty" bson:"category,omitempty"`
+	// Density is in grams per millilitre.
+	Density *float64 `json:"density,omitempty" bson:"density,omitempty"`
+	// Keys are the slugs of the name and of the synonyms, which ingredient
+	// lines are matched on. No two foods share a key.
+	Keys []string `json:"-" bson:"keys"`
+	// CreatedBy is who added the food to the catalog, nil for foods added
+	// from ingredient lines, which only admins may change.
+	CreatedBy *primitive.ObjectID `json:"created_by,omitempty" bson:"created_by,omitempty"`
+}
+
+// MakeFood returns a food with a fresh ID and the slug and keys of its name
+// and synonyms.
+func MakeFood(name string, synonyms []string, category *string, density *float64) *Food {
+	if synonyms == nil {
+		synonyms = []string{}
+	}
+	return &Food{
+		ID:       primitive.NewObjectID(),
+		Name:     name,
+		Slug:     text.Slugify(name),
+		Synonyms: synonyms,
+		Category: category,
+		Density:  density,
+		Keys:     FoodKeys(name, synonyms),
+	}
+}
+
+// FoodKeys returns the slugs a food with the given name and synonyms is
+// found by, without duplicates.
+func FoodKeys(name string, synonyms []string) []string {
+	keys := []string{}
+	for _, s := range append([]string{name}, synonyms...) {
+		if key := text.Slugify(s); key != "" && !contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Conflict returns the error to report when f would go by a key other
+// already has, or nil when they share none.
+func (f *Food) Conflict(other *Food) error {
+	for _, key := range f.Keys {
+		if !contains(other.Keys, key) {
+			continue
+		}
+		path := []string{"input", "synonyms"}
+		if key == f.Slug {
+			path = []string{"input", "name"}
+		}
+		return &apperr.Error{Code: apperr.Conflict, Message: fmt.Sprintf("%q already names %s", key, other.Name), Field: path}
+	}
+	return nil
+}
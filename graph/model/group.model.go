@@ -0,0 +1,103 @@
+package model
+
+// GroupedLine is an ingredient line of a recipe input along with the name
+// of the group it was given in, nil for the ungrouped lines.
+type GroupedLine[T any] struct {
+	Group      *string
+	Ingredient T
+}
+
+// Lines flattens the ingredient lines of in in the order they are stored:
+// the ungrouped ones, then the lines of each group.
+func (in *NewRecipe) Lines() []GroupedLine[*NewIngredient] {
+	var lines []GroupedLine[*NewIngredient]
+	for _, i := range in.Ingredients {
+		lines = append(lines, GroupedLine[*NewIngredient]{Ingredient: i})
+	}
+	for _, g := range in.IngredientGroups {
+		name := g.Name
+		for _, i := range g.Ingredients {
+			lines = append(lines, GroupedLine[*NewIngredient]{Group: &name, Ingredient: i})
+		}
+	}
+	return lines
+}
+
+// GroupNames lists the groups of in, in order.
+func (in *NewRecipe) GroupNames() []string {
+	var names []string
+	for _, g := range in.IngredientGroups {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// Lines is NewRecipe.Lines for updates.
+func (in *UpdateRecipe) Lines() []GroupedLine[*UpdateIngredient] {
+	var lines []GroupedLine[*UpdateIngredient]
+	for _, i := range in.Ingredients {
+		lines = append(lines, GroupedLine[*UpdateIngredient]{Ingredient: i})
+	}
+	for _, g := range in.IngredientGroups {
+		name := g.Name
+		for _, i := range g.Ingredients {
+			lines = append(lines, GroupedLine[*UpdateIngredient]{Group: &name, Ingredient: i})
+		}
+	}
+	return lines
+}
+
+// GroupNames lists the groups of in, in order.
+func (in *UpdateRecipe) GroupNames() []string {
+	var names []string
+	for _, g := range in.IngredientGroups {
+		names = append(names, g.Name)
+	}
+	return names
+}
+
+// GroupIngredients splits ingredients, in recipe order, into the groups
+// named in groups: the ungrouped lines first, in a group without a name,
+// then each group in order. Lines naming a group missing from groups get
+// one of their own after the others; groups without lines are left out.
+func GroupIngredients(groups []string, ingredients []*Ingredient) []*IngredientGroup {
+	byName := map[string]*IngredientGroup{}
+	ungrouped := &IngredientGroup{Ingredients: []*Ingredient{}}
+	var extra []*IngredientGroup
+	for _, i := range ingredients {
+		if i.Group == nil {
+			ungrouped.Ingredients = append(ungrouped.Ingredients, i)
+			continue
+		}
+		g, ok := byName[*i.Group]
+		if !ok {
+			name := *i.Group
+			g = &IngredientGroup{Name: &name, Ingredients: []*Ingredient{}}
+			byName[name] = g
+			if !contains(groups, name) {
+				extra = append(extra, g)
+			}
+		}
+		g.Ingredients = append(g.Ingredients, i)
+	}
+
+	out := []*IngredientGroup{}
+	if len(ungrouped.Ingredients) > 0 {
+		out = append(out, ungrouped)
+	}
+	for _, name := range groups {
+		if g, ok := byName[name]; ok {
+			out = append(out, g)
+		}
+	}
+	return append(out, extra...)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -1,22 +1,39 @@
 package model
 
 import (
-	pager "github.com/gobeam/mongo-go-pagination"
+	"github.com/ottolauncher/recipes/utils/quantity"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Ingredient struct {
-	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
-	Name       string              `json:"name"`
-	Slug       *string             `json:"slug,omitempty" bson:"slug,omitempty"`
-	Type       string              `json:"type"`
-	Quantity   string              `json:"quantity"`
-	RecipeID   primitive.ObjectID  `json:"recipe_id" bson:"recipe_id,omitempty"`
-	Pagination pager.PaginatedData `json:"pagination,omitempty"`
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name     string             `json:"name"`
+	Slug     *string            `json:"slug,omitempty" bson:"slug,omitempty"`
+	Type     string             `json:"type"`
+	Quantity string             `json:"quantity"`
+	Measure  *quantity.Quantity `json:"measure,omitempty" bson:"measure,omitempty"`
+	Group    *string            `json:"group,omitempty" bson:"group,omitempty"`
+	// FoodID is the catalog entry the line is made of.
+	FoodID   *primitive.ObjectID `json:"food_id,omitempty" bson:"food_id,omitempty"`
+	RecipeID primitive.ObjectID  `json:"recipe_id" bson:"recipe_id,omitempty"`
+	// CreatedBy and UpdatedBy are the users who wrote the line, nil for
+	// anonymous writes.
+	CreatedBy  *primitive.ObjectID `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	UpdatedBy  *primitive.ObjectID `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	Pagination Page                `json:"pagination,omitempty" bson:"-"`
 }
 
 func (i *Ingredient) IsBaseModel() {}
 
+// Measured returns the structured quantity stored with i, parsing the text
+// for ingredients written before it was.
+func (i *Ingredient) Measured() quantity.Quantity {
+	if i.Measure != nil {
+		return *i.Measure
+	}
+	return quantity.Parse(i.Quantity)
+}
+
 func (i *Ingredient) GetID() string {
 	return i.ID.Hex()
 }
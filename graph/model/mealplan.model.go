@@ -0,0 +1,60 @@
+package model
+
+import (
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DateLayout is how the days of meal plans are written.
+const DateLayout = "2006-01-02"
+
+// MealPlan assigns recipes to the meals of a calendar.
+type MealPlan struct {
+	ID      primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name    string             `json:"name"`
+	Entries []*MealPlanEntry   `json:"entries"`
+	// FeedToken is the secret part of the address the plan is served at as
+	// an iCalendar feed. Plans made before feeds had one have none, and are
+	// not served until it is reset.
+	FeedToken string `json:"-" bson:"feed_token,omitempty"`
+	// CreatedBy is the user the plan belongs to, nil for plans made before
+	// accounts existed, which are left to admins.
+	CreatedBy *primitive.ObjectID `json:"created_by,omitempty" bson:"created_by,omitempty"`
+}
+
+type MealPlanEntry struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id"`
+	RecipeID primitive.ObjectID `json:"recipe_id" bson:"recipe_id"`
+	// Date is the day of the meal in DateLayout, which sorts like the days.
+	Date     string   `json:"date"`
+	Slot     MealSlot `json:"slot"`
+	Servings *int     `json:"servings,omitempty" bson:"servings,omitempty"`
+}
+
+// Between returns the entries of p from the day from to the day to, both
+// included, by date and slot. Empty bounds leave that side open.
+func (p *MealPlan) Between(from, to string) []*MealPlanEntry {
+	entries := []*MealPlanEntry{}
+	for _, e := range p.Entries {
+		if (from == "" || e.Date >= from) && (to == "" || e.Date <= to) {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].Date != entries[b].Date {
+			return entries[a].Date < entries[b].Date
+		}
+		return entries[a].Slot.order() < entries[b].Slot.order()
+	})
+	return entries
+}
+
+func (s MealSlot) order() int {
+	for n, slot := range AllMealSlot {
+		if slot == s {
+			return n
+		}
+	}
+	return len(AllMealSlot)
+}
@@ -2,6 +2,13 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
 type BaseModel interface {
 	IsBaseModel()
 	GetID() string
@@ -13,21 +20,226 @@ type SearchRecipeResult interface {
 	IsSearchRecipeResult()
 }
 
+type APIKeyPayload struct {
+	APIKey *APIKey `json:"apiKey"`
+	// the key itself, only shown when it is issued or rotated
+	Key    *string      `json:"key"`
+	Errors []*UserError `json:"errors"`
+}
+
+// token goes in the Authorization header of later requests, as Bearer <token>
+type AuthPayload struct {
+	Token  *string      `json:"token"`
+	User   *User        `json:"user"`
+	Errors []*UserError `json:"errors"`
+}
+
+type BulkIngredientPayload struct {
+	// one result per input item, in input order
+	Results []*CreateIngredientPayload `json:"results"`
+}
+
+type BulkRecipePayload struct {
+	// one result per input item, in input order
+	Results []*CreateRecipePayload `json:"results"`
+}
+
+type CheckShoppingItem struct {
+	ListID  string `json:"listID"`
+	ItemID  string `json:"itemID"`
+	Checked bool   `json:"checked"`
+}
+
+type CreateFoodPayload struct {
+	Food   *Food        `json:"food"`
+	Errors []*UserError `json:"errors"`
+}
+
+type CreateIngredientPayload struct {
+	Ingredient *Ingredient  `json:"ingredient"`
+	Errors     []*UserError `json:"errors"`
+}
+
+type CreateMealPlanPayload struct {
+	MealPlan *MealPlan    `json:"mealPlan"`
+	Errors   []*UserError `json:"errors"`
+}
+
+type CreatePantryPayload struct {
+	Pantry *Pantry      `json:"pantry"`
+	Errors []*UserError `json:"errors"`
+}
+
+type CreateRecipePayload struct {
+	Recipe *Recipe      `json:"recipe"`
+	Errors []*UserError `json:"errors"`
+}
+
+type CreateShoppingListPayload struct {
+	ShoppingList *ShoppingList `json:"shoppingList"`
+	Errors       []*UserError  `json:"errors"`
+}
+
+type DeleteIngredientPayload struct {
+	Ingredient *Ingredient  `json:"ingredient"`
+	Errors     []*UserError `json:"errors"`
+}
+
+type DeleteMealPlanPayload struct {
+	MealPlan *MealPlan    `json:"mealPlan"`
+	Errors   []*UserError `json:"errors"`
+}
+
+type DeletePantryPayload struct {
+	Pantry *Pantry      `json:"pantry"`
+	Errors []*UserError `json:"errors"`
+}
+
+type DeleteRecipePayload struct {
+	Recipe *Recipe      `json:"recipe"`
+	Errors []*UserError `json:"errors"`
+}
+
+type DeleteShoppingListPayload struct {
+	ShoppingList *ShoppingList `json:"shoppingList"`
+	Errors       []*UserError  `json:"errors"`
+}
+
+// bounds on a duration, each an ISO 8601 duration or a phrase such as "30 min"
+type DurationFilter struct {
+	Lt  *string `json:"lt"`
+	Lte *string `json:"lte"`
+	Gt  *string `json:"gt"`
+	Gte *string `json:"gte"`
+}
+
+type FoodFilter struct {
+	Name     *StringFilter `json:"name"`
+	Slug     *StringFilter `json:"slug"`
+	Category *StringFilter `json:"category"`
+}
+
+type IngredientConnection struct {
+	Edges    []*IngredientEdge `json:"edges"`
+	PageInfo *PageInfo         `json:"pageInfo"`
+}
+
+type IngredientEdge struct {
+	Cursor string      `json:"cursor"`
+	Node   *Ingredient `json:"node"`
+}
+
+type IngredientGroup struct {
+	Name        *string       `json:"name"`
+	Ingredients []*Ingredient `json:"ingredients"`
+}
+
+type IngredientLine struct {
+	Text      string   `json:"text"`
+	Name      string   `json:"name"`
+	Amount    *float64 `json:"amount"`
+	AmountMax *float64 `json:"amountMax"`
+	Unit      *string  `json:"unit"`
+	Note      *string  `json:"note"`
+	Display   string   `json:"display"`
+}
+
+type Login struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type MealPlanShoppingList struct {
+	PlanID string `json:"planID"`
+	// first day, as YYYY-MM-DD
+	From string `json:"from"`
+	// last day, as YYYY-MM-DD
+	To    string      `json:"to"`
+	Name  *string     `json:"name"`
+	Units *UnitSystem `json:"units"`
+}
+
+type ModerateRecipe struct {
+	ID     string `json:"id"`
+	Locked bool   `json:"locked"`
+	// replaces the note; null clears it
+	Note *string `json:"note"`
+}
+
+type MoveMeal struct {
+	PlanID  string `json:"planID"`
+	EntryID string `json:"entryID"`
+	// as YYYY-MM-DD
+	Date string   `json:"date"`
+	Slot MealSlot `json:"slot"`
+}
+
+type NewAPIKey struct {
+	// who the key is for
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type NewFood struct {
+	Name     string   `json:"name"`
+	Synonyms []string `json:"synonyms"`
+	Category *string  `json:"category"`
+	Density  *float64 `json:"density"`
+}
+
 type NewIngredient struct {
 	Name     string `json:"name"`
 	Type     string `json:"type"`
 	Quantity string `json:"quantity"`
 }
 
-type NewRecipe struct {
+type NewIngredientGroup struct {
 	Name        string           `json:"name"`
-	Timers      []string         `json:"timers"`
-	Steps       []string         `json:"steps"`
-	ImageURL    string           `json:"imageURL"`
-	OriginalURL string           `json:"originalURL"`
 	Ingredients []*NewIngredient `json:"ingredients"`
 }
 
+type NewMealPlan struct {
+	Name string `json:"name"`
+}
+
+type NewPantry struct {
+	Name  string             `json:"name"`
+	Items []*PantryItemInput `json:"items"`
+}
+
+type NewRecipe struct {
+	Name    string        `json:"name"`
+	Timers  []string      `json:"timers"`
+	Timings []*TimerInput `json:"timings"`
+	Steps   []string      `json:"steps"`
+	// follows the deprecated steps, if any
+	Instructions []*StepInput `json:"instructions"`
+	ImageURL     string       `json:"imageURL"`
+	OriginalURL  string       `json:"originalURL"`
+	Servings     *int         `json:"servings"`
+	Yield        *string      `json:"yield"`
+	// lines outside any group
+	Ingredients []*NewIngredient `json:"ingredients"`
+	// sections of lines, in order, after the ungrouped ones
+	IngredientGroups []*NewIngredientGroup `json:"ingredientGroups"`
+}
+
+type NewShoppingList struct {
+	Name      *string  `json:"name"`
+	RecipeIDs []string `json:"recipeIDs"`
+	// servings to scale each recipe to, in the order of recipeIDs; null keeps a recipe's own
+	Servings []*int `json:"servings"`
+	// units to add quantities up in, the request's default units if null
+	Units *UnitSystem `json:"units"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
 type PaginationData struct {
 	Total     int `json:"total"`
 	Page      int `json:"page"`
@@ -37,6 +249,112 @@ type PaginationData struct {
 	TotalPage int `json:"totalPage"`
 }
 
+type PantryItemInput struct {
+	// kept on update when it names an item of the pantry
+	ID        *string    `json:"id"`
+	Name      string     `json:"name"`
+	Quantity  string     `json:"quantity"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type PlanMeal struct {
+	PlanID   string `json:"planID"`
+	RecipeID string `json:"recipeID"`
+	// as YYYY-MM-DD
+	Date     string   `json:"date"`
+	Slot     MealSlot `json:"slot"`
+	Servings *int     `json:"servings"`
+}
+
+type RecipeConnection struct {
+	Edges    []*RecipeEdge `json:"edges"`
+	PageInfo *PageInfo     `json:"pageInfo"`
+}
+
+type RecipeEdge struct {
+	Cursor string  `json:"cursor"`
+	Node   *Recipe `json:"node"`
+}
+
+type RecipeEvent struct {
+	Type   RecipeEventType `json:"type"`
+	Recipe *Recipe         `json:"recipe"`
+}
+
+// recipes without the ordered time come last
+type RecipeOrder struct {
+	Field     RecipeOrderField `json:"field"`
+	Direction OrderDirection   `json:"direction"`
+}
+
+type Register struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	// at least 8 characters
+	Password string `json:"password"`
+}
+
+type SearchConnection struct {
+	Edges    []*SearchRecipeResultEdge `json:"edges"`
+	PageInfo *PageInfo                 `json:"pageInfo"`
+}
+
+type SearchRecipeResultEdge struct {
+	Cursor string             `json:"cursor"`
+	Node   SearchRecipeResult `json:"node"`
+}
+
+type ShoppingAisle struct {
+	// null for the items whose food has no category
+	Category *string         `json:"category"`
+	Items    []*ShoppingItem `json:"items"`
+}
+
+type StepInput struct {
+	Text string `json:"text"`
+	// ISO 8601, such as PT10M, or a phrase such as "10 min"
+	Duration *string `json:"duration"`
+	// such as "180 °C" or "350°F"
+	Temperature *string `json:"temperature"`
+	// indexes of the lines the step uses, counting the ungrouped ingredients and then the lines of each group; the lines get their IDs when the recipe is saved
+	Ingredients []int    `json:"ingredients"`
+	Equipment   []string `json:"equipment"`
+	ImageURL    *string  `json:"imageURL"`
+}
+
+type StringFilter struct {
+	Eq       *string  `json:"eq"`
+	In       []string `json:"in"`
+	Contains *string  `json:"contains"`
+}
+
+type TimeRange struct {
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+}
+
+type TimerInput struct {
+	// ISO 8601, such as PT1H30M, or a phrase such as "1 hr 30 min"
+	Duration string    `json:"duration"`
+	Kind     TimerKind `json:"kind"`
+	// index in instructions of the step the timer belongs to
+	Step  *int    `json:"step"`
+	Label *string `json:"label"`
+}
+
+type UpdateFood struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Synonyms []string `json:"synonyms"`
+	Category *string  `json:"category"`
+	Density  *float64 `json:"density"`
+}
+
+type UpdateFoodPayload struct {
+	Food   *Food        `json:"food"`
+	Errors []*UserError `json:"errors"`
+}
+
 type UpdateIngredient struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
@@ -44,12 +362,425 @@ type UpdateIngredient struct {
 	Quantity string `json:"quantity"`
 }
 
-type UpdateRecipe struct {
-	ID          string              `json:"id"`
+type UpdateIngredientGroup struct {
 	Name        string              `json:"name"`
-	Timers      []string            `json:"timers"`
-	Steps       []string            `json:"steps"`
-	ImageURL    string              `json:"imageURL"`
-	OriginalURL string              `json:"originalURL"`
 	Ingredients []*UpdateIngredient `json:"ingredients"`
 }
+
+type UpdateIngredientPayload struct {
+	Ingredient *Ingredient  `json:"ingredient"`
+	Errors     []*UserError `json:"errors"`
+}
+
+type UpdateMealPlanPayload struct {
+	MealPlan *MealPlan    `json:"mealPlan"`
+	Errors   []*UserError `json:"errors"`
+}
+
+type UpdatePantry struct {
+	ID    string             `json:"id"`
+	Name  string             `json:"name"`
+	Items []*PantryItemInput `json:"items"`
+}
+
+type UpdatePantryPayload struct {
+	Pantry *Pantry      `json:"pantry"`
+	Errors []*UserError `json:"errors"`
+}
+
+type UpdateRecipe struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Timers  []string      `json:"timers"`
+	Timings []*TimerInput `json:"timings"`
+	Steps   []string      `json:"steps"`
+	// follows the deprecated steps, if any
+	Instructions []*StepInput `json:"instructions"`
+	ImageURL     string       `json:"imageURL"`
+	OriginalURL  string       `json:"originalURL"`
+	Servings     *int         `json:"servings"`
+	Yield        *string      `json:"yield"`
+	// lines outside any group
+	Ingredients []*UpdateIngredient `json:"ingredients"`
+	// sections of lines, in order, after the ungrouped ones
+	IngredientGroups []*UpdateIngredientGroup `json:"ingredientGroups"`
+}
+
+type UpdateRecipePayload struct {
+	Recipe *Recipe      `json:"recipe"`
+	Errors []*UserError `json:"errors"`
+}
+
+type UpdateShoppingListPayload struct {
+	ShoppingList *ShoppingList `json:"shoppingList"`
+	Errors       []*UserError  `json:"errors"`
+}
+
+type UpdateUserPayload struct {
+	User   *User        `json:"user"`
+	Errors []*UserError `json:"errors"`
+}
+
+type UserError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// path to the input field the error is about
+	Field []string `json:"field"`
+}
+
+type ErrorCode string
+
+const (
+	ErrorCodeNotFound        ErrorCode = "NOT_FOUND"
+	ErrorCodeValidation      ErrorCode = "VALIDATION"
+	ErrorCodeConflict        ErrorCode = "CONFLICT"
+	ErrorCodeUnauthenticated ErrorCode = "UNAUTHENTICATED"
+	ErrorCodeForbidden       ErrorCode = "FORBIDDEN"
+	ErrorCodeInternal        ErrorCode = "INTERNAL"
+)
+
+var AllErrorCode = []ErrorCode{
+	ErrorCodeNotFound,
+	ErrorCodeValidation,
+	ErrorCodeConflict,
+	ErrorCodeUnauthenticated,
+	ErrorCodeForbidden,
+	ErrorCodeInternal,
+}
+
+func (e ErrorCode) IsValid() bool {
+	switch e {
+	case ErrorCodeNotFound, ErrorCodeValidation, ErrorCodeConflict, ErrorCodeUnauthenticated, ErrorCodeForbidden, ErrorCodeInternal:
+		return true
+	}
+	return false
+}
+
+func (e ErrorCode) String() string {
+	return string(e)
+}
+
+func (e *ErrorCode) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ErrorCode(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ErrorCode", str)
+	}
+	return nil
+}
+
+func (e ErrorCode) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type MealSlot string
+
+const (
+	MealSlotBreakfast MealSlot = "BREAKFAST"
+	MealSlotLunch     MealSlot = "LUNCH"
+	MealSlotDinner    MealSlot = "DINNER"
+)
+
+var AllMealSlot = []MealSlot{
+	MealSlotBreakfast,
+	MealSlotLunch,
+	MealSlotDinner,
+}
+
+func (e MealSlot) IsValid() bool {
+	switch e {
+	case MealSlotBreakfast, MealSlotLunch, MealSlotDinner:
+		return true
+	}
+	return false
+}
+
+func (e MealSlot) String() string {
+	return string(e)
+}
+
+func (e *MealSlot) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = MealSlot(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid MealSlot", str)
+	}
+	return nil
+}
+
+func (e MealSlot) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderDirection string
+
+const (
+	OrderDirectionAsc  OrderDirection = "ASC"
+	OrderDirectionDesc OrderDirection = "DESC"
+)
+
+var AllOrderDirection = []OrderDirection{
+	OrderDirectionAsc,
+	OrderDirectionDesc,
+}
+
+func (e OrderDirection) IsValid() bool {
+	switch e {
+	case OrderDirectionAsc, OrderDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e OrderDirection) String() string {
+	return string(e)
+}
+
+func (e *OrderDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderDirection", str)
+	}
+	return nil
+}
+
+func (e OrderDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type RecipeEventType string
+
+const (
+	RecipeEventTypeCreated RecipeEventType = "CREATED"
+	RecipeEventTypeUpdated RecipeEventType = "UPDATED"
+	RecipeEventTypeDeleted RecipeEventType = "DELETED"
+)
+
+var AllRecipeEventType = []RecipeEventType{
+	RecipeEventTypeCreated,
+	RecipeEventTypeUpdated,
+	RecipeEventTypeDeleted,
+}
+
+func (e RecipeEventType) IsValid() bool {
+	switch e {
+	case RecipeEventTypeCreated, RecipeEventTypeUpdated, RecipeEventTypeDeleted:
+		return true
+	}
+	return false
+}
+
+func (e RecipeEventType) String() string {
+	return string(e)
+}
+
+func (e *RecipeEventType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RecipeEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RecipeEventType", str)
+	}
+	return nil
+}
+
+func (e RecipeEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type RecipeOrderField string
+
+const (
+	RecipeOrderFieldName      RecipeOrderField = "NAME"
+	RecipeOrderFieldCreatedAt RecipeOrderField = "CREATED_AT"
+	RecipeOrderFieldPrepTime  RecipeOrderField = "PREP_TIME"
+	RecipeOrderFieldCookTime  RecipeOrderField = "COOK_TIME"
+	RecipeOrderFieldTotalTime RecipeOrderField = "TOTAL_TIME"
+)
+
+var AllRecipeOrderField = []RecipeOrderField{
+	RecipeOrderFieldName,
+	RecipeOrderFieldCreatedAt,
+	RecipeOrderFieldPrepTime,
+	RecipeOrderFieldCookTime,
+	RecipeOrderFieldTotalTime,
+}
+
+func (e RecipeOrderField) IsValid() bool {
+	switch e {
+	case RecipeOrderFieldName, RecipeOrderFieldCreatedAt, RecipeOrderFieldPrepTime, RecipeOrderFieldCookTime, RecipeOrderFieldTotalTime:
+		return true
+	}
+	return false
+}
+
+func (e RecipeOrderField) String() string {
+	return string(e)
+}
+
+func (e *RecipeOrderField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RecipeOrderField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RecipeOrderField", str)
+	}
+	return nil
+}
+
+func (e RecipeOrderField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Role string
+
+const (
+	RoleUser  Role = "USER"
+	RoleAdmin Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleUser,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleUser, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TimerKind string
+
+const (
+	// work done before cooking, such as chopping
+	TimerKindPrep TimerKind = "PREP"
+	// time on the heat or in the oven
+	TimerKindCook TimerKind = "COOK"
+	// waiting, such as proofing or chilling
+	TimerKindRest TimerKind = "REST"
+)
+
+var AllTimerKind = []TimerKind{
+	TimerKindPrep,
+	TimerKindCook,
+	TimerKindRest,
+}
+
+func (e TimerKind) IsValid() bool {
+	switch e {
+	case TimerKindPrep, TimerKindCook, TimerKindRest:
+		return true
+	}
+	return false
+}
+
+func (e TimerKind) String() string {
+	return string(e)
+}
+
+func (e *TimerKind) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TimerKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TimerKind", str)
+	}
+	return nil
+}
+
+func (e TimerKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// the units ingredient quantities are shown in
+type UnitSystem string
+
+const (
+	// grams, millilitres and degrees Celsius
+	UnitSystemMetric UnitSystem = "METRIC"
+	// ounces, pounds, cups and degrees Fahrenheit
+	UnitSystemUs UnitSystem = "US"
+	// as the recipe was written
+	UnitSystemOriginal UnitSystem = "ORIGINAL"
+)
+
+var AllUnitSystem = []UnitSystem{
+	UnitSystemMetric,
+	UnitSystemUs,
+	UnitSystemOriginal,
+}
+
+func (e UnitSystem) IsValid() bool {
+	switch e {
+	case UnitSystemMetric, UnitSystemUs, UnitSystemOriginal:
+		return true
+	}
+	return false
+}
+
+func (e UnitSystem) String() string {
+	return string(e)
+}
+
+func (e *UnitSystem) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = UnitSystem(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid UnitSystem", str)
+	}
+	return nil
+}
+
+func (e UnitSystem) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
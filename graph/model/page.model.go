@@ -0,0 +1,13 @@
+package model
+
+// Page says where the results of a paginated query sit among all those
+// matching it. Both storage backends fill it in, so it does not depend on
+// how either of them counts.
+type Page struct {
+	Total     int
+	Page      int
+	PerPage   int
+	Prev      int
+	Next      int
+	TotalPage int
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Pantry is what a kitchen has on hand, which recipes are matched against.
+type Pantry struct {
+	ID    primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name  string             `json:"name"`
+	Items []*PantryItem      `json:"items"`
+	// CreatedBy is the user the pantry belongs to, nil for pantries made
+	// before accounts existed, which are left to admins.
+	CreatedBy *primitive.ObjectID `json:"created_by,omitempty" bson:"created_by,omitempty"`
+}
+
+// PantryItem points to its food the way ingredient lines do, by name.
+type PantryItem struct {
+	ID       primitive.ObjectID  `json:"id" bson:"_id"`
+	Name     string              `json:"name"`
+	FoodID   *primitive.ObjectID `json:"food_id,omitempty" bson:"food_id,omitempty"`
+	Quantity string              `json:"quantity"`
+	Measure  *quantity.Quantity  `json:"measure,omitempty" bson:"measure,omitempty"`
+	// ExpiresAt is nil for items that keep.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" bson:"expires_at,omitempty"`
+}
+
+// Expired reports whether i is past its expiry date at now.
+func (i *PantryItem) Expired(now time.Time) bool {
+	return i.ExpiresAt != nil && !i.ExpiresAt.After(now)
+}
+
+// NewPantryItems builds the items of a pantry from input, keeping the IDs
+// of those that name one of existing and giving the others fresh ones.
+func NewPantryItems(input []*PantryItemInput, existing []*PantryItem) []*PantryItem {
+	keep := make(map[primitive.ObjectID]bool, len(existing))
+	for _, i := range existing {
+		keep[i.ID] = true
+	}
+	items := []*PantryItem{}
+	for _, in := range input {
+		id := primitive.NewObjectID()
+		if in.ID != nil {
+			if oid, err := primitive.ObjectIDFromHex(*in.ID); err == nil && keep[oid] {
+				id = oid
+				delete(keep, oid)
+			}
+		}
+		measure := quantity.Parse(in.Quantity)
+		items = append(items, &PantryItem{
+			ID:        id,
+			Name:      in.Name,
+			Quantity:  in.Quantity,
+			Measure:   &measure,
+			ExpiresAt: in.ExpiresAt,
+		})
+	}
+	return items
+}
+
+// CookableRecipe is a recipe ranked against a pantry by the foods it has
+// on hand.
+type CookableRecipe struct {
+	Recipe   *Recipe       `json:"recipe"`
+	Score    int           `json:"score"`
+	Coverage float64       `json:"coverage"`
+	Have     []*Ingredient `json:"have"`
+	Missing  []*Ingredient `json:"missing"`
+	Expiring []*PantryItem `json:"expiring"`
+}
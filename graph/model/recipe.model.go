@@ -1,7 +1,8 @@
 package model
 
 import (
-	pager "github.com/gobeam/mongo-go-pagination"
+	"sort"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -9,13 +10,29 @@ type Recipe struct {
 	ID            primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
 	Name          string               `json:"name"`
 	Slug          *string              `json:"slug,omitempty" bson:"slug,omitempty"`
-	Timers        []string             `json:"timers"`
-	Steps         []string             `json:"steps"`
+	Timers        []string             `json:"timers,omitempty" bson:"timers,omitempty"`
+	Timings       []*Timer             `json:"timings,omitempty" bson:"timings,omitempty"`
+	PrepTime      *int                 `json:"prepTime,omitempty" bson:"prep_time,omitempty"`
+	CookTime      *int                 `json:"cookTime,omitempty" bson:"cook_time,omitempty"`
+	TotalTime     *int                 `json:"totalTime,omitempty" bson:"total_time,omitempty"`
+	Steps         []string             `json:"steps,omitempty" bson:"steps,omitempty"`
+	Instructions  []*Step              `json:"instructions,omitempty" bson:"instructions,omitempty"`
 	ImageURL      string               `json:"imageURL" bson:"imageURL"`
 	OriginalURL   *string              `json:"originalURL" bson:"originalURL"`
+	Servings      *int                 `json:"servings,omitempty" bson:"servings,omitempty"`
+	Yield         *string              `json:"yield,omitempty" bson:"yield,omitempty"`
 	Ingredients   []*Ingredient        `json:"ingredients" bson:"ingredients"`
-	IngredientIDs []primitive.ObjectID `json:"ingredient_ids,omitempty" bson:"ingredient_ids,omiempty"`
-	Pagination    pager.PaginatedData  `json:"pagination,omitempty"`
+	IngredientIDs []primitive.ObjectID `json:"ingredient_ids,omitempty" bson:"ingredient_ids,omitempty"`
+	Groups        []string             `json:"ingredient_groups,omitempty" bson:"ingredient_groups,omitempty"`
+	// CreatedBy and UpdatedBy are the users who wrote the recipe, nil for
+	// anonymous writes.
+	CreatedBy *primitive.ObjectID `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	UpdatedBy *primitive.ObjectID `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	// Locked and ModerationNote are set by admins. Only admins may change
+	// locked recipes.
+	Locked         bool    `json:"locked,omitempty" bson:"locked,omitempty"`
+	ModerationNote *string `json:"moderationNote,omitempty" bson:"moderation_note,omitempty"`
+	Pagination     Page    `json:"pagination,omitempty" bson:"-"`
 }
 
 func (r *Recipe) IsBaseModel() {}
@@ -33,3 +50,25 @@ func (r *Recipe) GetSlug() *string {
 }
 
 func (r *Recipe) IsSearchRecipeResult() {}
+
+// OrderIngredients returns a copy of ingredients sorted in the order of ids,
+// the ingredient_ids a recipe stores. Lines missing from ids, such as
+// ingredients created on their own and attached later, come after them.
+func OrderIngredients(ids []primitive.ObjectID, ingredients []*Ingredient) []*Ingredient {
+	rank := make(map[primitive.ObjectID]int, len(ids))
+	for n, id := range ids {
+		rank[id] = n
+	}
+	position := func(i *Ingredient) int {
+		if n, ok := rank[i.ID]; ok {
+			return n
+		}
+		return len(rank)
+	}
+
+	ordered := append([]*Ingredient{}, ingredients...)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		return position(ordered[a]) < position(ordered[b])
+	})
+	return ordered
+}
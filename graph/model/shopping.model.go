@@ -0,0 +1,85 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ShoppingList is what to buy for a few recipes, one item per food. Saved
+// lists are a snapshot: later changes to the recipes do not reach them.
+type ShoppingList struct {
+	// ID is zero for lists built without being saved.
+	ID      primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	Name    *string               `json:"name,omitempty" bson:"name,omitempty"`
+	Units   UnitSystem            `json:"units"`
+	Recipes []*ShoppingListRecipe `json:"recipes"`
+	Items   []*ShoppingItem       `json:"items"`
+	// CreatedBy is the user the list belongs to, nil for lists saved
+	// before accounts existed, which are left to admins.
+	CreatedBy *primitive.ObjectID `json:"created_by,omitempty" bson:"created_by,omitempty"`
+}
+
+// ShoppingListRecipe is a recipe a list was made for, with the servings it
+// was scaled to; nil servings keep the recipe's own.
+type ShoppingListRecipe struct {
+	RecipeID primitive.ObjectID `json:"recipe_id" bson:"recipe_id"`
+	Servings *int               `json:"servings,omitempty" bson:"servings,omitempty"`
+}
+
+type ShoppingItem struct {
+	ID     primitive.ObjectID  `json:"id" bson:"_id"`
+	FoodID *primitive.ObjectID `json:"food_id,omitempty" bson:"food_id,omitempty"`
+	Name   string              `json:"name"`
+	// Category is the category of the food, which stands for the aisle.
+	Category   *string              `json:"category,omitempty" bson:"category,omitempty"`
+	Quantities []quantity.Quantity  `json:"quantities" bson:"quantities"`
+	Checked    bool                 `json:"checked"`
+	RecipeIDs  []primitive.ObjectID `json:"recipe_ids" bson:"recipe_ids"`
+}
+
+// Quantity renders the summed quantities of i: "600 g, 3 cloves, to taste".
+func (i *ShoppingItem) Quantity() string {
+	parts := make([]string, len(i.Quantities))
+	for n, q := range i.Quantities {
+		parts[n] = q.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Aisles returns the items of l by category, categories in alphabetical
+// order and items without one last, each sorted by name.
+func (l *ShoppingList) Aisles() []*ShoppingAisle {
+	aisles := []*ShoppingAisle{}
+	byCategory := map[string]*ShoppingAisle{}
+	for _, i := range l.Items {
+		key := ""
+		if i.Category != nil {
+			key = *i.Category
+		}
+		a := byCategory[key]
+		if a == nil {
+			a = &ShoppingAisle{Category: i.Category}
+			byCategory[key] = a
+			aisles = append(aisles, a)
+		}
+		a.Items = append(a.Items, i)
+	}
+	sort.SliceStable(aisles, func(a, b int) bool {
+		switch {
+		case aisles[a].Category == nil:
+			return false
+		case aisles[b].Category == nil:
+			return true
+		}
+		return strings.ToLower(*aisles[a].Category) < strings.ToLower(*aisles[b].Category)
+	})
+	for _, a := range aisles {
+		sort.SliceStable(a.Items, func(x, y int) bool {
+			return strings.ToLower(a.Items[x].Name) < strings.ToLower(a.Items[y].Name)
+		})
+	}
+	return aisles
+}
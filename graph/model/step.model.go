@@ -0,0 +1,79 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ottolauncher/recipes/utils/duration"
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Step struct {
+	Text          string               `json:"text" bson:"text"`
+	Seconds       *int                 `json:"seconds,omitempty" bson:"seconds,omitempty"`
+	Temperature   *quantity.Quantity   `json:"temperature,omitempty" bson:"temperature,omitempty"`
+	IngredientIDs []primitive.ObjectID `json:"ingredientIDs" bson:"ingredient_ids"`
+	Equipment     []string             `json:"equipment" bson:"equipment"`
+	ImageURL      *string              `json:"imageURL,omitempty" bson:"imageURL,omitempty"`
+}
+
+// Duration is the step's duration in ISO 8601, nil when it has none.
+func (s *Step) Duration() *string {
+	if s.Seconds == nil {
+		return nil
+	}
+	iso := duration.ISO(time.Duration(*s.Seconds) * time.Second)
+	return &iso
+}
+
+// NewSteps builds the steps of a recipe from its input: the plain texts of
+// the deprecated steps field followed by instructions. ingredients are the
+// IDs the recipe's ingredient lines were given, in input order, which is
+// what the ingredient indexes of instructions point into. Inputs are
+// validated beforehand.
+func NewSteps(steps []string, instructions []*StepInput, ingredients []primitive.ObjectID) []*Step {
+	out := []*Step{}
+	for _, text := range steps {
+		out = append(out, &Step{Text: text, IngredientIDs: []primitive.ObjectID{}, Equipment: []string{}})
+	}
+	for _, in := range instructions {
+		step := &Step{Text: in.Text, IngredientIDs: []primitive.ObjectID{}, Equipment: []string{}, ImageURL: in.ImageURL}
+		if in.Duration != nil {
+			d, _ := duration.Parse(*in.Duration)
+			seconds := int(d / time.Second)
+			step.Seconds = &seconds
+		}
+		if in.Temperature != nil {
+			t := quantity.Parse(*in.Temperature)
+			step.Temperature = &t
+		}
+		for _, n := range in.Ingredients {
+			if n >= 0 && n < len(ingredients) {
+				step.IngredientIDs = append(step.IngredientIDs, ingredients[n])
+			}
+		}
+		for _, e := range in.Equipment {
+			if e = strings.TrimSpace(e); e != "" {
+				step.Equipment = append(step.Equipment, e)
+			}
+		}
+		out = append(out, step)
+	}
+	return out
+}
+
+// Structured returns the steps of r, upgrading the plain texts older
+// versions stored in Steps when it has no Instructions.
+func (r *Recipe) Structured() []*Step {
+	if r.Instructions != nil || r.Steps == nil {
+		return r.Instructions
+	}
+	return NewSteps(r.Steps, nil, nil)
+}
+
+// SetSteps stores steps in r in place of any plain texts.
+func (r *Recipe) SetSteps(steps []*Step) {
+	r.Steps = nil
+	r.Instructions = steps
+}
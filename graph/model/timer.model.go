@@ -0,0 +1,93 @@
+package model
+
+import (
+	"time"
+
+	"github.com/ottolauncher/recipes/utils/duration"
+)
+
+type Timer struct {
+	Text    string    `json:"text" bson:"text"`
+	Seconds int       `json:"seconds" bson:"seconds"`
+	Kind    TimerKind `json:"kind" bson:"kind"`
+	Step    *int      `json:"step,omitempty" bson:"step,omitempty"`
+	Label   *string   `json:"label,omitempty" bson:"label,omitempty"`
+}
+
+// Duration is the timer in ISO 8601.
+func (t *Timer) Duration() string {
+	return duration.ISO(time.Duration(t.Seconds) * time.Second)
+}
+
+// NewTimers builds the timers of a recipe from its input: the bare
+// durations of the deprecated timers field, which are COOK timers, followed
+// by timings. Bare texts that are no duration, such as "overnight", make no
+// timer; Untimed keeps them. Timings are validated beforehand.
+func NewTimers(timers []string, timings []*TimerInput) []*Timer {
+	out := []*Timer{}
+	for _, text := range timers {
+		if _, err := duration.Parse(text); err == nil {
+			out = append(out, newTimer(text, TimerKindCook, nil, nil))
+		}
+	}
+	for _, t := range timings {
+		out = append(out, newTimer(t.Duration, t.Kind, t.Step, t.Label))
+	}
+	return out
+}
+
+func newTimer(text string, kind TimerKind, step *int, label *string) *Timer {
+	d, _ := duration.Parse(text)
+	return &Timer{Text: text, Seconds: int(d / time.Second), Kind: kind, Step: step, Label: label}
+}
+
+// Untimed returns the bare timer texts that are no duration, which recipes
+// keep as they were written in Timers. It is nil when there are none.
+func Untimed(timers []string) []string {
+	var out []string
+	for _, text := range timers {
+		if _, err := duration.Parse(text); err != nil {
+			out = append(out, text)
+		}
+	}
+	return out
+}
+
+// Timed returns the timers of r, reading the bare durations older versions
+// stored in Timers when it has no Timings.
+func (r *Recipe) Timed() []*Timer {
+	if r.Timings != nil || r.Timers == nil {
+		return r.Timings
+	}
+	return NewTimers(r.Timers, nil)
+}
+
+// SetTimers stores timers in r along with the prep, cook and total times
+// computed from them, which is what filters and sorts read, and keeps the
+// untimed texts in Timers.
+func (r *Recipe) SetTimers(timers []*Timer, untimed []string) {
+	r.Timers = untimed
+	r.Timings = timers
+	r.PrepTime, r.CookTime, r.TotalTime = TimerTotals(timers)
+}
+
+// TimerTotals adds up the seconds of the PREP timers, of the COOK timers
+// and of all of them. Each is nil when there is nothing to add.
+func TimerTotals(timers []*Timer) (prep, cook, total *int) {
+	add := func(sum **int, seconds int) {
+		if *sum == nil {
+			*sum = new(int)
+		}
+		**sum += seconds
+	}
+	for _, t := range timers {
+		switch t.Kind {
+		case TimerKindPrep:
+			add(&prep, t.Seconds)
+		case TimerKindCook:
+			add(&cook, t.Seconds)
+		}
+		add(&total, t.Seconds)
+	}
+	return prep, cook, total
+}
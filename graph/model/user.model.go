@@ -0,0 +1,24 @@
+package model
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type User struct {
+	ID primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	// Email is stored as NormalizeEmail returns it; no two users share one.
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	// Role is empty for users registered before roles existed, who are
+	// plain users.
+	Role Role `json:"role" bson:"role,omitempty"`
+	// PasswordHash is the bcrypt hash of the password, never sent out.
+	PasswordHash []byte `json:"-" bson:"password_hash"`
+}
+
+// NormalizeEmail returns the form emails are stored and looked up in.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
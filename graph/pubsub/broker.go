@@ -0,0 +1,77 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dgryski/trifles/uuid"
+	"github.com/ottolauncher/recipes/graph/model"
+)
+
+// Broker carries recipe events from the mutation that produced them to every
+// subscription, possibly on other replicas.
+type Broker interface {
+	Publish(ctx context.Context, ev *model.RecipeEvent) error
+	// Subscribe returns a channel of events that is closed once ctx is done.
+	Subscribe(ctx context.Context) (<-chan *model.RecipeEvent, error)
+	Close() error
+}
+
+// subscriberBuffer is how many events a slow subscriber may lag behind
+// before it starts missing them.
+const subscriberBuffer = 16
+
+// MemoryBroker delivers events to subscribers of the current process only.
+type MemoryBroker struct {
+	mu        sync.Mutex
+	observers map[string]chan *model.RecipeEvent
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{observers: map[string]chan *model.RecipeEvent{}}
+}
+
+// Publish never blocks: an observer whose buffer is full misses the event
+// instead of stalling the caller.
+func (b *MemoryBroker) Publish(ctx context.Context, ev *model.RecipeEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.observers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context) (<-chan *model.RecipeEvent, error) {
+	id := uuid.UUIDv4()
+	events := make(chan *model.RecipeEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	b.observers[id] = events
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		if _, ok := b.observers[id]; ok {
+			delete(b.observers, id)
+			close(events)
+		}
+		b.mu.Unlock()
+	}()
+	return events, nil
+}
+
+// Close ends every open subscription.
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, ch := range b.observers {
+		delete(b.observers, id)
+		close(ch)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ottolauncher/recipes/graph/model"
+)
+
+func event(name string) *model.RecipeEvent {
+	return &model.RecipeEvent{Type: model.RecipeEventTypeCreated, Recipe: &model.Recipe{Name: name}}
+}
+
+// receive returns the next event of events, failing t when none comes.
+func receive(t *testing.T, events <-chan *model.RecipeEvent) *model.RecipeEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return nil
+}
+
+// closed reports whether events gets closed before long, dropping what is
+// left in it.
+func closed(events <-chan *model.RecipeEvent) bool {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+func TestMemoryBrokerFanOut(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+	ctx := context.Background()
+	first, _ := b.Subscribe(ctx)
+	second, _ := b.Subscribe(ctx)
+
+	if err := b.Publish(ctx, event("Soup")); err != nil {
+		t.Fatal(err)
+	}
+	for n, events := range []<-chan *model.RecipeEvent{first, second} {
+		if ev := receive(t, events); ev.Recipe.Name != "Soup" {
+			t.Errorf("subscriber %d got %q, want Soup", n, ev.Recipe.Name)
+		}
+	}
+}
+
+func TestMemoryBrokerDropsWhenFull(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+	ctx := context.Background()
+	slow, _ := b.Subscribe(ctx)
+
+	published := make(chan struct{})
+	go func() {
+		for n := 0; n < subscriberBuffer+5; n++ {
+			b.Publish(ctx, event(string(rune('a'+n))))
+		}
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscriber")
+	}
+	if len(slow) != subscriberBuffer {
+		t.Errorf("subscriber holds %d events, want %d", len(slow), subscriberBuffer)
+	}
+	if ev := receive(t, slow); ev.Recipe.Name != "a" {
+		t.Errorf("first event = %q, want the oldest one kept", ev.Recipe.Name)
+	}
+}
+
+func TestMemoryBrokerUnsubscribe(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	events, _ := b.Subscribe(ctx)
+	kept, _ := b.Subscribe(context.Background())
+
+	cancel()
+	if !closed(events) {
+		t.Fatal("subscription not closed once its context is done")
+	}
+	if err := b.Publish(context.Background(), event("Soup")); err != nil {
+		t.Fatal(err)
+	}
+	if ev := receive(t, kept); ev.Recipe.Name != "Soup" {
+		t.Errorf("remaining subscriber got %q, want Soup", ev.Recipe.Name)
+	}
+}
+
+func TestMemoryBrokerClose(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	events, _ := b.Subscribe(ctx)
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !closed(events) {
+		t.Fatal("subscription not closed by Close")
+	}
+	// the subscription is gone already, ending its context must not close
+	// the channel twice; give the goroutine watching it the time to panic
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+}
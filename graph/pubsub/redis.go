@@ -0,0 +1,74 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/ottolauncher/recipes/graph/model"
+)
+
+// RedisBroker shares events between replicas through a Redis channel. Each
+// process holds a single Redis subscription and fans the messages out to its
+// own subscribers, so a replica also receives the events it publishes.
+type RedisBroker struct {
+	client  *redis.Client
+	channel string
+	sub     *redis.PubSub
+	local   *MemoryBroker
+}
+
+// NewRedisBroker connects to the Redis server described by opts and starts
+// listening on channel.
+func NewRedisBroker(ctx context.Context, opts *redis.Options, channel string) (*RedisBroker, error) {
+	client := redis.NewClient(opts)
+	sub := client.Subscribe(ctx, channel)
+	// wait for the subscription to be confirmed so no event published right
+	// after startup is lost
+	if _, err := sub.Receive(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	b := &RedisBroker{
+		client:  client,
+		channel: channel,
+		sub:     sub,
+		local:   NewMemoryBroker(),
+	}
+	go b.listen()
+	return b, nil
+}
+
+func (b *RedisBroker) listen() {
+	for msg := range b.sub.Channel() {
+		var ev model.RecipeEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+			log.Println("pubsub: dropping malformed event:", err)
+			continue
+		}
+		b.local.Publish(context.Background(), &ev)
+	}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, ev *model.RecipeEvent) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context) (<-chan *model.RecipeEvent, error) {
+	return b.local.Subscribe(ctx)
+}
+
+func (b *RedisBroker) Close() error {
+	err := b.sub.Close()
+	b.local.Close()
+	if cerr := b.client.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/ottolauncher/recipes/graph/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRedisBroker(t *testing.T) {
+	s := miniredis.RunT(t)
+	ctx := context.Background()
+	b, err := NewRedisBroker(ctx, &redis.Options{Addr: s.Addr()}, "recipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	events, _ := b.Subscribe(ctx)
+
+	// malformed messages, from another publisher, are dropped
+	s.Publish("recipes", "not an event")
+	id := primitive.NewObjectID()
+	url := "https://example.com/soup"
+	sent := &model.RecipeEvent{Type: model.RecipeEventTypeUpdated, Recipe: &model.Recipe{ID: id, Name: "Soup", OriginalURL: &url}}
+	if err := b.Publish(ctx, sent); err != nil {
+		t.Fatal(err)
+	}
+	got := receive(t, events)
+	if got.Type != sent.Type || got.Recipe.ID != id || got.Recipe.Name != "Soup" || got.Recipe.OriginalURL == nil || *got.Recipe.OriginalURL != url {
+		t.Errorf("received %+v with recipe %+v, want %+v", got, got.Recipe, sent.Recipe)
+	}
+}
+
+func TestRedisBrokerUnreachable(t *testing.T) {
+	s := miniredis.RunT(t)
+	addr := s.Addr()
+	s.Close()
+	if _, err := NewRedisBroker(context.Background(), &redis.Options{Addr: addr, MaxRetries: -1}, "recipes"); err == nil {
+		t.Fatal("NewRedisBroker connected to a closed server")
+	}
+}
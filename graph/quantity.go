@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/quantity"
+)
+
+// ingredientLine is what parseIngredientLine shows a client before it saves
+// the line.
+func ingredientLine(text string) *model.IngredientLine {
+	line := quantity.ParseLine(text)
+	return &model.IngredientLine{
+		Text:      text,
+		Name:      line.Name,
+		Amount:    line.Amount,
+		AmountMax: line.Max,
+		Unit:      optional(line.Unit),
+		Note:      optional(line.Note),
+		Display:   line.Quantity.String(),
+	}
+}
+
+// optional maps the empty string to null.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -2,21 +2,61 @@
 package graph
 
 import (
-	"sync"
+	"context"
+	"log"
 
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
 	db "github.com/ottolauncher/recipes/graph/db/mongo"
 	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/graph/pubsub"
 )
 
 // This file will not be regenerated automatically.
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
-// TODO use redis subscription instead of inmemory one
 type Resolver struct {
-	RM              *db.RecipeManager
-	IM              *db.IngredientManager
-	Recipes         []*model.Recipe
-	RecipeObservers map[string]chan []*model.Recipe
-	mu              sync.Mutex
+	RM db.IRecipe
+	IM db.Ingredient
+	FM db.Food
+	SM db.ShoppingList
+	PM db.Pantry
+	MP db.MealPlan
+	UM db.User
+	AK db.APIKey
+	// Auth issues the tokens users log in with.
+	Auth *auth.Issuer
+	// Admins are the emails of users who are admins whatever role they
+	// are stored with, so a fresh deployment has someone to hand out roles.
+	Admins []string
+	Broker pubsub.Broker
+	// ChangeStream is set when a database watcher publishes the events, in
+	// which case mutations must not publish them a second time.
+	ChangeStream bool
+	// AllowRawFilters enables the admin only raw argument of queries.
+	AllowRawFilters bool
+}
+
+// rawFilter returns raw when the server accepts raw filters and fails
+// otherwise, so a client cannot slip operators past the typed filters.
+func (r *Resolver) rawFilter(raw map[string]interface{}) (map[string]interface{}, error) {
+	if raw != nil && !r.AllowRawFilters {
+		return nil, apperr.New(apperr.Forbidden, "raw filters are disabled on this server")
+	}
+	return raw, nil
+}
+
+// publish sends one event of the given kind per recipe to the broker. The
+// mutation already succeeded at this point, so a broker failure is logged
+// rather than reported to the client.
+func (r *Resolver) publish(ctx context.Context, kind model.RecipeEventType, recipes ...*model.Recipe) {
+	if r.ChangeStream {
+		return
+	}
+	for _, recipe := range recipes {
+		if err := r.Broker.Publish(ctx, &model.RecipeEvent{Type: kind, Recipe: recipe}); err != nil {
+			log.Println("publish recipe event:", err)
+		}
+	}
 }
@@ -0,0 +1,37 @@
+package graph
+
+import (
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/quantity"
+)
+
+// scaleRecipe returns a copy of recipe made for servings: its ingredient
+// quantities, and its yield when that starts with a number, are multiplied
+// by servings over the servings the recipe was written for.
+func scaleRecipe(recipe *model.Recipe, servings int) (*model.Recipe, error) {
+	if servings < 1 {
+		return nil, apperr.Invalid([]string{"servings"}, "servings must be at least 1")
+	}
+	if recipe.Servings == nil || *recipe.Servings < 1 {
+		return nil, apperr.New(apperr.Validation, "recipe %q does not say how many it serves", recipe.Name)
+	}
+	factor := float64(servings) / float64(*recipe.Servings)
+
+	scaled := *recipe
+	scaled.Servings = &servings
+	if recipe.Yield != nil {
+		if y := quantity.Parse(*recipe.Yield); y.Amount != nil {
+			yield := quantity.Scale(y, factor).String()
+			scaled.Yield = &yield
+		}
+	}
+	scaled.Ingredients = make([]*model.Ingredient, len(recipe.Ingredients))
+	for n, i := range recipe.Ingredients {
+		ingredient := *i
+		measure := quantity.Scale(i.Measured(), factor)
+		ingredient.Measure = &measure
+		scaled.Ingredients[n] = &ingredient
+	}
+	return &scaled, nil
+}
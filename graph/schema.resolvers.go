@@ -3,104 +3,674 @@ package graph
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
-	"github.com/dgryski/trifles/uuid"
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
 	"github.com/ottolauncher/recipes/graph/generated"
+	"github.com/ottolauncher/recipes/graph/loader"
 	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/preloads"
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // This file will be automatically regenerated based on the schema, any resolver implementations
 // will be copied through when generating and any unknown code will be moved to the end.
 
+// ID is the resolver for the id field.
+func (r *aPIKeyResolver) ID(ctx context.Context, obj *model.APIKey) (string, error) {
+	return obj.ID.Hex(), nil
+}
+
+// CreatedBy is the resolver for the createdBy field.
+func (r *aPIKeyResolver) CreatedBy(ctx context.Context, obj *model.APIKey) (*model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).UserByID.Load(ctx, *obj.CreatedBy)()
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *aPIKeyResolver) CreatedAt(ctx context.Context, obj *model.APIKey) (*time.Time, error) {
+	created := obj.ID.Timestamp()
+	return &created, nil
+}
+
+// ID is the resolver for the id field.
+func (r *foodResolver) ID(ctx context.Context, obj *model.Food) (string, error) {
+	return obj.ID.Hex(), nil
+}
+
+// Recipes is the resolver for the recipes field.
+func (r *foodResolver) Recipes(ctx context.Context, obj *model.Food, first *int, after *string) (*model.RecipeConnection, error) {
+	slug := obj.Slug
+	return r.Query().RecipesConnection(ctx, &model.RecipeFilter{HasIngredient: &slug}, nil, first, after)
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *foodResolver) CreatedAt(ctx context.Context, obj *model.Food) (*time.Time, error) {
+	created := obj.ID.Timestamp()
+	return &created, nil
+}
+
+// CreatedBy is the resolver for the createdBy field.
+func (r *foodResolver) CreatedBy(ctx context.Context, obj *model.Food) (*model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).UserByID.Load(ctx, *obj.CreatedBy)()
+}
+
 // ID is the resolver for the id field.
 func (r *ingredientResolver) ID(ctx context.Context, obj *model.Ingredient) (string, error) {
 	return obj.ID.Hex(), nil
 }
 
+// Amount is the resolver for the amount field.
+func (r *ingredientResolver) Amount(ctx context.Context, obj *model.Ingredient) (*float64, error) {
+	return obj.Measured().Amount, nil
+}
+
+// AmountMax is the resolver for the amountMax field.
+func (r *ingredientResolver) AmountMax(ctx context.Context, obj *model.Ingredient) (*float64, error) {
+	return obj.Measured().Max, nil
+}
+
+// Unit is the resolver for the unit field.
+func (r *ingredientResolver) Unit(ctx context.Context, obj *model.Ingredient) (*string, error) {
+	return optional(obj.Measured().Unit), nil
+}
+
+// Display is the resolver for the display field.
+func (r *ingredientResolver) Display(ctx context.Context, obj *model.Ingredient) (string, error) {
+	return obj.Measured().String(), nil
+}
+
+// Food is the resolver for the food field.
+func (r *ingredientResolver) Food(ctx context.Context, obj *model.Ingredient) (*model.Food, error) {
+	if obj.FoodID == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).FoodByID.Load(ctx, *obj.FoodID)()
+}
+
 // RecipeID is the resolver for the recipeID field.
 func (r *ingredientResolver) RecipeID(ctx context.Context, obj *model.Ingredient) (string, error) {
 	return obj.RecipeID.Hex(), nil
 }
 
+// Recipe is the resolver for the recipe field.
+func (r *ingredientResolver) Recipe(ctx context.Context, obj *model.Ingredient) (*model.Recipe, error) {
+	if obj.RecipeID.IsZero() {
+		return nil, nil
+	}
+	return loader.For(ctx).RecipeByID.Load(ctx, obj.RecipeID)()
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *ingredientResolver) CreatedAt(ctx context.Context, obj *model.Ingredient) (*time.Time, error) {
+	created := obj.ID.Timestamp()
+	return &created, nil
+}
+
+// CreatedBy is the resolver for the createdBy field.
+func (r *ingredientResolver) CreatedBy(ctx context.Context, obj *model.Ingredient) (*model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).UserByID.Load(ctx, *obj.CreatedBy)()
+}
+
+// UpdatedBy is the resolver for the updatedBy field.
+func (r *ingredientResolver) UpdatedBy(ctx context.Context, obj *model.Ingredient) (*model.User, error) {
+	if obj.UpdatedBy == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).UserByID.Load(ctx, *obj.UpdatedBy)()
+}
+
 // Pagination is the resolver for the pagination field.
 func (r *ingredientResolver) Pagination(ctx context.Context, obj *model.Ingredient) (*model.PaginationData, error) {
 	return &model.PaginationData{
-		Total:     int(obj.Pagination.Pagination.Total),
-		Page:      int(obj.Pagination.Pagination.Page),
-		PerPage:   int(obj.Pagination.Pagination.PerPage),
-		Prev:      int(obj.Pagination.Pagination.Prev),
-		Next:      int(obj.Pagination.Pagination.Next),
-		TotalPage: int(obj.Pagination.Pagination.TotalPage),
+		Total:     obj.Pagination.Total,
+		Page:      obj.Pagination.Page,
+		PerPage:   obj.Pagination.PerPage,
+		Prev:      obj.Pagination.Prev,
+		Next:      obj.Pagination.Next,
+		TotalPage: obj.Pagination.TotalPage,
 	}, nil
 }
 
+// ID is the resolver for the id field.
+func (r *mealPlanResolver) ID(ctx context.Context, obj *model.MealPlan) (string, error) {
+	return obj.ID.Hex(), nil
+}
+
+// Entries is the resolver for the entries field.
+func (r *mealPlanResolver) Entries(ctx context.Context, obj *model.MealPlan, from *string, to *string) ([]*model.MealPlanEntry, error) {
+	var first, last string
+	if from != nil {
+		first = *from
+	}
+	if to != nil {
+		last = *to
+	}
+	return obj.Between(first, last), nil
+}
+
+// CalendarURL is the resolver for the calendarURL field.
+func (r *mealPlanResolver) CalendarURL(ctx context.Context, obj *model.MealPlan) (*string, error) {
+	if obj.FeedToken == "" {
+		return nil, nil
+	}
+	path := CalendarPath(obj.ID.Hex(), obj.FeedToken)
+	return &path, nil
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *mealPlanResolver) CreatedAt(ctx context.Context, obj *model.MealPlan) (*time.Time, error) {
+	created := obj.ID.Timestamp()
+	return &created, nil
+}
+
+// CreatedBy is the resolver for the createdBy field.
+func (r *mealPlanResolver) CreatedBy(ctx context.Context, obj *model.MealPlan) (*model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).UserByID.Load(ctx, *obj.CreatedBy)()
+}
+
+// ID is the resolver for the id field.
+func (r *mealPlanEntryResolver) ID(ctx context.Context, obj *model.MealPlanEntry) (string, error) {
+	return obj.ID.Hex(), nil
+}
+
+// Recipe is the resolver for the recipe field.
+func (r *mealPlanEntryResolver) Recipe(ctx context.Context, obj *model.MealPlanEntry) (*model.Recipe, error) {
+	return loader.For(ctx).RecipeByID.Load(ctx, obj.RecipeID)()
+}
+
 // CreateIngredient is the resolver for the createIngredient field.
-func (r *mutationResolver) CreateIngredient(ctx context.Context, input model.NewIngredient) (bool, error) {
-	if err := r.IM.Create(ctx, &input); err != nil {
-		return false, err
+func (r *mutationResolver) CreateIngredient(ctx context.Context, input model.NewIngredient) (*model.CreateIngredientPayload, error) {
+	if errs := validateNewIngredient([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.CreateIngredientPayload{Errors: uerrs}, err
 	}
-	return true, nil
+	ingredient, err := r.IM.Create(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.CreateIngredientPayload{Errors: uerrs}, err
+	}
+	return &model.CreateIngredientPayload{Ingredient: ingredient, Errors: []*model.UserError{}}, nil
 }
 
 // BulkIngredient is the resolver for the bulkIngredient field.
-func (r *mutationResolver) BulkIngredient(ctx context.Context, input []*model.NewIngredient) (bool, error) {
-	if err := r.IM.Bulk(ctx, input); err != nil {
-		return false, err
+func (r *mutationResolver) BulkIngredient(ctx context.Context, input []*model.NewIngredient) (*model.BulkIngredientPayload, error) {
+	results := make([]*model.CreateIngredientPayload, len(input))
+	var (
+		valid []*model.NewIngredient
+		index []int
+	)
+	for n, in := range input {
+		if errs := validateNewIngredient([]string{"input", strconv.Itoa(n)}, in); len(errs) > 0 {
+			uerrs, err := report(ctx, errs...)
+			if err != nil {
+				return nil, err
+			}
+			results[n] = &model.CreateIngredientPayload{Errors: uerrs}
+			continue
+		}
+		valid = append(valid, in)
+		index = append(index, n)
+	}
+
+	if len(valid) > 0 {
+		ingredients, err := r.IM.Bulk(ctx, valid)
+		if err != nil {
+			return nil, err
+		}
+		for n, ingredient := range ingredients {
+			results[index[n]] = &model.CreateIngredientPayload{Ingredient: ingredient, Errors: []*model.UserError{}}
+		}
 	}
-	return true, nil
+	return &model.BulkIngredientPayload{Results: results}, nil
 }
 
 // UpdateIngredient is the resolver for the updateIngredient field.
-func (r *mutationResolver) UpdateIngredient(ctx context.Context, input *model.UpdateIngredient) (bool, error) {
-	if err := r.IM.Update(ctx, input); err != nil {
-		return false, err
+func (r *mutationResolver) UpdateIngredient(ctx context.Context, input *model.UpdateIngredient) (*model.UpdateIngredientPayload, error) {
+	if errs := validateUpdateIngredient([]string{"input"}, input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.UpdateIngredientPayload{Errors: uerrs}, err
 	}
-	return true, nil
+	ingredient, err := r.IM.Update(ctx, input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateIngredientPayload{Errors: uerrs}, err
+	}
+	return &model.UpdateIngredientPayload{Ingredient: ingredient, Errors: []*model.UserError{}}, nil
 }
 
 // DeleteIngredient is the resolver for the deleteIngredient field.
-func (r *mutationResolver) DeleteIngredient(ctx context.Context, filter map[string]interface{}) (bool, error) {
-	if err := r.IM.Delete(ctx, filter); err != nil {
-		return false, err
+func (r *mutationResolver) DeleteIngredient(ctx context.Context, filter model.IngredientFilter, raw map[string]interface{}) (*model.DeleteIngredientPayload, error) {
+	var err error
+	if filter.Raw, err = r.rawFilter(raw); err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.DeleteIngredientPayload{Errors: uerrs}, err
+	}
+	ingredient, err := r.IM.Delete(ctx, &filter)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.DeleteIngredientPayload{Errors: uerrs}, err
 	}
-	return true, nil
+	return &model.DeleteIngredientPayload{Ingredient: ingredient, Errors: []*model.UserError{}}, nil
 }
 
 // CreateRecipe is the resolver for the createRecipe field.
-func (r *mutationResolver) CreateRecipe(ctx context.Context, input model.NewRecipe) (bool, error) {
-	if err := r.RM.Create(ctx, &input); err != nil {
-		return false, err
+func (r *mutationResolver) CreateRecipe(ctx context.Context, input model.NewRecipe) (*model.CreateRecipePayload, error) {
+	if errs := validateNewRecipe([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.CreateRecipePayload{Errors: uerrs}, err
+	}
+	recipe, err := r.RM.Create(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.CreateRecipePayload{Errors: uerrs}, err
 	}
-	return true, nil
+	r.publish(ctx, model.RecipeEventTypeCreated, recipe)
+	return &model.CreateRecipePayload{Recipe: recipe, Errors: []*model.UserError{}}, nil
 }
 
 // BulkRecipe is the resolver for the bulkRecipe field.
-func (r *mutationResolver) BulkRecipe(ctx context.Context, input []*model.NewRecipe) (bool, error) {
-	if err := r.RM.Bulk(ctx, input); err != nil {
-		return false, err
+func (r *mutationResolver) BulkRecipe(ctx context.Context, input []*model.NewRecipe) (*model.BulkRecipePayload, error) {
+	results := make([]*model.CreateRecipePayload, len(input))
+	var (
+		valid []*model.NewRecipe
+		index []int
+	)
+	for n, in := range input {
+		if errs := validateNewRecipe([]string{"input", strconv.Itoa(n)}, in); len(errs) > 0 {
+			uerrs, err := report(ctx, errs...)
+			if err != nil {
+				return nil, err
+			}
+			results[n] = &model.CreateRecipePayload{Errors: uerrs}
+			continue
+		}
+		valid = append(valid, in)
+		index = append(index, n)
 	}
-	return true, nil
+
+	if len(valid) > 0 {
+		recipes, err := r.RM.Bulk(ctx, valid)
+		if err != nil {
+			return nil, err
+		}
+		r.publish(ctx, model.RecipeEventTypeCreated, recipes...)
+		for n, recipe := range recipes {
+			results[index[n]] = &model.CreateRecipePayload{Recipe: recipe, Errors: []*model.UserError{}}
+		}
+	}
+	return &model.BulkRecipePayload{Results: results}, nil
 }
 
 // UpdateRecipe is the resolver for the updateRecipe field.
-func (r *mutationResolver) UpdateRecipe(ctx context.Context, input model.UpdateRecipe) (bool, error) {
-	if err := r.RM.Update(ctx, &input); err != nil {
-		return false, err
+func (r *mutationResolver) UpdateRecipe(ctx context.Context, input model.UpdateRecipe) (*model.UpdateRecipePayload, error) {
+	if errs := validateUpdateRecipe([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.UpdateRecipePayload{Errors: uerrs}, err
+	}
+	recipe, err := r.RM.Update(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateRecipePayload{Errors: uerrs}, err
 	}
-	return true, nil
+	r.publish(ctx, model.RecipeEventTypeUpdated, recipe)
+	return &model.UpdateRecipePayload{Recipe: recipe, Errors: []*model.UserError{}}, nil
 }
 
 // DeleteRecipe is the resolver for the deleteRecipe field.
-func (r *mutationResolver) DeleteRecipe(ctx context.Context, filter map[string]interface{}) (bool, error) {
-	if err := r.RM.Delete(ctx, filter); err != nil {
-		return false, err
+func (r *mutationResolver) DeleteRecipe(ctx context.Context, filter model.RecipeFilter, raw map[string]interface{}) (*model.DeleteRecipePayload, error) {
+	var err error
+	if filter.Raw, err = r.rawFilter(raw); err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.DeleteRecipePayload{Errors: uerrs}, err
+	}
+	recipe, err := r.RM.Delete(ctx, &filter)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.DeleteRecipePayload{Errors: uerrs}, err
+	}
+	r.publish(ctx, model.RecipeEventTypeDeleted, recipe)
+	return &model.DeleteRecipePayload{Recipe: recipe, Errors: []*model.UserError{}}, nil
+}
+
+// Register is the resolver for the register field.
+func (r *mutationResolver) Register(ctx context.Context, input model.Register) (*model.AuthPayload, error) {
+	if errs := validateRegister([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.AuthPayload{Errors: uerrs}, err
+	}
+	payload, err := r.register(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.AuthPayload{Errors: uerrs}, err
+	}
+	return payload, nil
+}
+
+// Login is the resolver for the login field.
+func (r *mutationResolver) Login(ctx context.Context, input model.Login) (*model.AuthPayload, error) {
+	payload, err := r.login(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.AuthPayload{Errors: uerrs}, err
+	}
+	return payload, nil
+}
+
+// SetUserRole is the resolver for the setUserRole field.
+func (r *mutationResolver) SetUserRole(ctx context.Context, id string, role model.Role) (*model.UpdateUserPayload, error) {
+	user, err := r.UM.SetRole(ctx, id, role)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateUserPayload{Errors: uerrs}, err
+	}
+	return &model.UpdateUserPayload{User: user, Errors: []*model.UserError{}}, nil
+}
+
+// IssueAPIKey is the resolver for the issueAPIKey field.
+func (r *mutationResolver) IssueAPIKey(ctx context.Context, input model.NewAPIKey) (*model.APIKeyPayload, error) {
+	if errs := validateNewAPIKey([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.APIKeyPayload{Errors: uerrs}, err
+	}
+	payload, err := r.issueAPIKey(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.APIKeyPayload{Errors: uerrs}, err
+	}
+	return payload, nil
+}
+
+// RotateAPIKey is the resolver for the rotateAPIKey field.
+func (r *mutationResolver) RotateAPIKey(ctx context.Context, id string) (*model.APIKeyPayload, error) {
+	payload, err := r.rotateAPIKey(ctx, id)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.APIKeyPayload{Errors: uerrs}, err
+	}
+	return payload, nil
+}
+
+// RevokeAPIKey is the resolver for the revokeAPIKey field.
+func (r *mutationResolver) RevokeAPIKey(ctx context.Context, id string) (*model.APIKeyPayload, error) {
+	payload, err := r.revokeAPIKey(ctx, id)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.APIKeyPayload{Errors: uerrs}, err
+	}
+	return payload, nil
+}
+
+// ModerateRecipe is the resolver for the moderateRecipe field.
+func (r *mutationResolver) ModerateRecipe(ctx context.Context, input model.ModerateRecipe) (*model.UpdateRecipePayload, error) {
+	recipe, err := r.RM.Moderate(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateRecipePayload{Errors: uerrs}, err
+	}
+	return &model.UpdateRecipePayload{Recipe: recipe, Errors: []*model.UserError{}}, nil
+}
+
+// CreateFood is the resolver for the createFood field.
+func (r *mutationResolver) CreateFood(ctx context.Context, input model.NewFood) (*model.CreateFoodPayload, error) {
+	if errs := validateNewFood([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.CreateFoodPayload{Errors: uerrs}, err
+	}
+	food, err := r.FM.Create(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.CreateFoodPayload{Errors: uerrs}, err
+	}
+	return &model.CreateFoodPayload{Food: food, Errors: []*model.UserError{}}, nil
+}
+
+// UpdateFood is the resolver for the updateFood field.
+func (r *mutationResolver) UpdateFood(ctx context.Context, input model.UpdateFood) (*model.UpdateFoodPayload, error) {
+	if errs := validateUpdateFood([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.UpdateFoodPayload{Errors: uerrs}, err
+	}
+	food, err := r.FM.Update(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateFoodPayload{Errors: uerrs}, err
+	}
+	return &model.UpdateFoodPayload{Food: food, Errors: []*model.UserError{}}, nil
+}
+
+// CreateShoppingList is the resolver for the createShoppingList field.
+func (r *mutationResolver) CreateShoppingList(ctx context.Context, input model.NewShoppingList) (*model.CreateShoppingListPayload, error) {
+	list, err := r.shoppingList(ctx, []string{"input"}, input.RecipeIDs, input.Servings, input.Units)
+	if err == nil {
+		list.Name = input.Name
+		list, err = r.SM.Create(ctx, list)
+	}
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.CreateShoppingListPayload{Errors: uerrs}, err
+	}
+	return &model.CreateShoppingListPayload{ShoppingList: list, Errors: []*model.UserError{}}, nil
+}
+
+// CheckShoppingItem is the resolver for the checkShoppingItem field.
+func (r *mutationResolver) CheckShoppingItem(ctx context.Context, input model.CheckShoppingItem) (*model.UpdateShoppingListPayload, error) {
+	list, err := r.SM.Check(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateShoppingListPayload{Errors: uerrs}, err
+	}
+	return &model.UpdateShoppingListPayload{ShoppingList: list, Errors: []*model.UserError{}}, nil
+}
+
+// DeleteShoppingList is the resolver for the deleteShoppingList field.
+func (r *mutationResolver) DeleteShoppingList(ctx context.Context, id string) (*model.DeleteShoppingListPayload, error) {
+	list, err := r.SM.Delete(ctx, id)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.DeleteShoppingListPayload{Errors: uerrs}, err
 	}
-	return true, nil
+	return &model.DeleteShoppingListPayload{ShoppingList: list, Errors: []*model.UserError{}}, nil
+}
+
+// CreatePantry is the resolver for the createPantry field.
+func (r *mutationResolver) CreatePantry(ctx context.Context, input model.NewPantry) (*model.CreatePantryPayload, error) {
+	if errs := validateNewPantry([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.CreatePantryPayload{Errors: uerrs}, err
+	}
+	pantry, err := r.PM.Create(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.CreatePantryPayload{Errors: uerrs}, err
+	}
+	return &model.CreatePantryPayload{Pantry: pantry, Errors: []*model.UserError{}}, nil
+}
+
+// UpdatePantry is the resolver for the updatePantry field.
+func (r *mutationResolver) UpdatePantry(ctx context.Context, input model.UpdatePantry) (*model.UpdatePantryPayload, error) {
+	if errs := validateUpdatePantry([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.UpdatePantryPayload{Errors: uerrs}, err
+	}
+	pantry, err := r.PM.Update(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdatePantryPayload{Errors: uerrs}, err
+	}
+	return &model.UpdatePantryPayload{Pantry: pantry, Errors: []*model.UserError{}}, nil
+}
+
+// DeletePantry is the resolver for the deletePantry field.
+func (r *mutationResolver) DeletePantry(ctx context.Context, id string) (*model.DeletePantryPayload, error) {
+	pantry, err := r.PM.Delete(ctx, id)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.DeletePantryPayload{Errors: uerrs}, err
+	}
+	return &model.DeletePantryPayload{Pantry: pantry, Errors: []*model.UserError{}}, nil
+}
+
+// CreateMealPlan is the resolver for the createMealPlan field.
+func (r *mutationResolver) CreateMealPlan(ctx context.Context, input model.NewMealPlan) (*model.CreateMealPlanPayload, error) {
+	if errs := validateNewMealPlan([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.CreateMealPlanPayload{Errors: uerrs}, err
+	}
+	var plan *model.MealPlan
+	token, err := newFeedToken()
+	if err == nil {
+		plan, err = r.MP.Create(ctx, &input, token)
+	}
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.CreateMealPlanPayload{Errors: uerrs}, err
+	}
+	return &model.CreateMealPlanPayload{MealPlan: plan, Errors: []*model.UserError{}}, nil
+}
+
+// PlanMeal is the resolver for the planMeal field.
+func (r *mutationResolver) PlanMeal(ctx context.Context, input model.PlanMeal) (*model.UpdateMealPlanPayload, error) {
+	if errs := validatePlanMeal([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.UpdateMealPlanPayload{Errors: uerrs}, err
+	}
+	if err := r.checkRecipe(ctx, []string{"input", "recipeID"}, input.RecipeID); err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateMealPlanPayload{Errors: uerrs}, err
+	}
+	plan, err := r.MP.Plan(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateMealPlanPayload{Errors: uerrs}, err
+	}
+	return &model.UpdateMealPlanPayload{MealPlan: plan, Errors: []*model.UserError{}}, nil
+}
+
+// MoveMeal is the resolver for the moveMeal field.
+func (r *mutationResolver) MoveMeal(ctx context.Context, input model.MoveMeal) (*model.UpdateMealPlanPayload, error) {
+	if errs := validateMoveMeal([]string{"input"}, &input); len(errs) > 0 {
+		uerrs, err := report(ctx, errs...)
+		return &model.UpdateMealPlanPayload{Errors: uerrs}, err
+	}
+	plan, err := r.MP.Move(ctx, &input)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateMealPlanPayload{Errors: uerrs}, err
+	}
+	return &model.UpdateMealPlanPayload{MealPlan: plan, Errors: []*model.UserError{}}, nil
+}
+
+// RemoveMeal is the resolver for the removeMeal field.
+func (r *mutationResolver) RemoveMeal(ctx context.Context, planID string, entryID string) (*model.UpdateMealPlanPayload, error) {
+	plan, err := r.MP.Remove(ctx, planID, entryID)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateMealPlanPayload{Errors: uerrs}, err
+	}
+	return &model.UpdateMealPlanPayload{MealPlan: plan, Errors: []*model.UserError{}}, nil
+}
+
+// DeleteMealPlan is the resolver for the deleteMealPlan field.
+func (r *mutationResolver) DeleteMealPlan(ctx context.Context, id string) (*model.DeleteMealPlanPayload, error) {
+	plan, err := r.MP.Delete(ctx, id)
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.DeleteMealPlanPayload{Errors: uerrs}, err
+	}
+	return &model.DeleteMealPlanPayload{MealPlan: plan, Errors: []*model.UserError{}}, nil
+}
+
+// ResetCalendarFeed is the resolver for the resetCalendarFeed field.
+func (r *mutationResolver) ResetCalendarFeed(ctx context.Context, planID string) (*model.UpdateMealPlanPayload, error) {
+	var plan *model.MealPlan
+	token, err := newFeedToken()
+	if err == nil {
+		plan, err = r.MP.ResetFeed(ctx, planID, token)
+	}
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.UpdateMealPlanPayload{Errors: uerrs}, err
+	}
+	return &model.UpdateMealPlanPayload{MealPlan: plan, Errors: []*model.UserError{}}, nil
+}
+
+// CreateShoppingListFromPlan is the resolver for the createShoppingListFromPlan field.
+func (r *mutationResolver) CreateShoppingListFromPlan(ctx context.Context, input model.MealPlanShoppingList) (*model.CreateShoppingListPayload, error) {
+	list, err := r.planShoppingList(ctx, []string{"input"}, &input)
+	if err == nil {
+		list, err = r.SM.Create(ctx, list)
+	}
+	if err != nil {
+		uerrs, err := report(ctx, err)
+		return &model.CreateShoppingListPayload{Errors: uerrs}, err
+	}
+	return &model.CreateShoppingListPayload{ShoppingList: list, Errors: []*model.UserError{}}, nil
+}
+
+// ID is the resolver for the id field.
+func (r *pantryResolver) ID(ctx context.Context, obj *model.Pantry) (string, error) {
+	return obj.ID.Hex(), nil
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *pantryResolver) CreatedAt(ctx context.Context, obj *model.Pantry) (*time.Time, error) {
+	created := obj.ID.Timestamp()
+	return &created, nil
+}
+
+// CreatedBy is the resolver for the createdBy field.
+func (r *pantryResolver) CreatedBy(ctx context.Context, obj *model.Pantry) (*model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).UserByID.Load(ctx, *obj.CreatedBy)()
+}
+
+// ID is the resolver for the id field.
+func (r *pantryItemResolver) ID(ctx context.Context, obj *model.PantryItem) (string, error) {
+	return obj.ID.Hex(), nil
+}
+
+// Food is the resolver for the food field.
+func (r *pantryItemResolver) Food(ctx context.Context, obj *model.PantryItem) (*model.Food, error) {
+	if obj.FoodID == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).FoodByID.Load(ctx, *obj.FoodID)()
+}
+
+// Viewer is the resolver for the viewer field.
+func (r *queryResolver) Viewer(ctx context.Context) (*model.User, error) {
+	return auth.ForContext(ctx), nil
+}
+
+// APIKeys is the resolver for the apiKeys field.
+func (r *queryResolver) APIKeys(ctx context.Context, limit *int, page *int) ([]*model.APIKey, error) {
+	return r.AK.All(ctx, *limit, *page)
 }
 
 // Ingredient is the resolver for the ingredient field.
-func (r *queryResolver) Ingredient(ctx context.Context, filter map[string]interface{}) (*model.Ingredient, error) {
-	res, err := r.IM.Get(ctx, filter)
+func (r *queryResolver) Ingredient(ctx context.Context, filter model.IngredientFilter, raw map[string]interface{}) (*model.Ingredient, error) {
+	var err error
+	if filter.Raw, err = r.rawFilter(raw); err != nil {
+		return nil, err
+	}
+	res, err := r.IM.Get(ctx, &filter, preloads.GetFields(ctx, ""))
 	if err != nil {
 		return nil, err
 	}
@@ -108,8 +678,15 @@ func (r *queryResolver) Ingredient(ctx context.Context, filter map[string]interf
 }
 
 // Ingredients is the resolver for the ingredients field.
-func (r *queryResolver) Ingredients(ctx context.Context, filter map[string]interface{}, limit *int, page *int) ([]*model.Ingredient, error) {
-	res, err := r.IM.All(ctx, filter, *limit, *page)
+func (r *queryResolver) Ingredients(ctx context.Context, filter *model.IngredientFilter, raw map[string]interface{}, limit *int, page *int) ([]*model.Ingredient, error) {
+	if filter == nil {
+		filter = &model.IngredientFilter{}
+	}
+	var err error
+	if filter.Raw, err = r.rawFilter(raw); err != nil {
+		return nil, err
+	}
+	res, err := r.IM.All(ctx, filter, preloads.GetFields(ctx, ""), *limit, *page)
 	if err != nil {
 		return nil, err
 	}
@@ -117,23 +694,97 @@ func (r *queryResolver) Ingredients(ctx context.Context, filter map[string]inter
 }
 
 // Recipe is the resolver for the recipe field.
-func (r *queryResolver) Recipe(ctx context.Context, filter map[string]interface{}) (*model.Recipe, error) {
-	res, err := r.RM.Get(ctx, filter)
+func (r *queryResolver) Recipe(ctx context.Context, filter model.RecipeFilter, raw map[string]interface{}) (*model.Recipe, error) {
+	var err error
+	if filter.Raw, err = r.rawFilter(raw); err != nil {
+		return nil, err
+	}
+	res, err := r.RM.Get(ctx, &filter, preloads.GetFields(ctx, ""))
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
+// ScaledRecipe is the resolver for the scaledRecipe field.
+func (r *queryResolver) ScaledRecipe(ctx context.Context, id string, servings int) (*model.Recipe, error) {
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, apperr.Invalid([]string{"id"}, "invalid id %q", id)
+	}
+	// the servings and every ingredient are needed whatever the selection
+	recipe, err := r.RM.Get(ctx, &model.RecipeFilter{ID: &id}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return scaleRecipe(recipe, servings)
+}
+
 // Recipes is the resolver for the recipes field.
-func (r *queryResolver) Recipes(ctx context.Context, filter map[string]interface{}, limit *int, page *int) ([]*model.Recipe, error) {
-	res, err := r.RM.All(ctx, filter, *limit, *page)
+func (r *queryResolver) Recipes(ctx context.Context, filter *model.RecipeFilter, raw map[string]interface{}, limit *int, page *int, orderBy *model.RecipeOrder) ([]*model.Recipe, error) {
+	if filter == nil {
+		filter = &model.RecipeFilter{}
+	}
+	var err error
+	if filter.Raw, err = r.rawFilter(raw); err != nil {
+		return nil, err
+	}
+	res, err := r.RM.All(ctx, filter, orderBy, preloads.GetFields(ctx, ""), *limit, *page)
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
+// Food is the resolver for the food field.
+func (r *queryResolver) Food(ctx context.Context, slug string) (*model.Food, error) {
+	return r.FM.Get(ctx, slug)
+}
+
+// Foods is the resolver for the foods field.
+func (r *queryResolver) Foods(ctx context.Context, filter *model.FoodFilter, limit *int, page *int) ([]*model.Food, error) {
+	return r.FM.All(ctx, filter, *limit, *page)
+}
+
+// ShoppingList is the resolver for the shoppingList field.
+func (r *queryResolver) ShoppingList(ctx context.Context, recipeIDs []string, servings []*int, units *model.UnitSystem) (*model.ShoppingList, error) {
+	return r.shoppingList(ctx, nil, recipeIDs, servings, units)
+}
+
+// SavedShoppingList is the resolver for the savedShoppingList field.
+func (r *queryResolver) SavedShoppingList(ctx context.Context, id string) (*model.ShoppingList, error) {
+	return r.SM.Get(ctx, id)
+}
+
+// ShoppingLists is the resolver for the shoppingLists field.
+func (r *queryResolver) ShoppingLists(ctx context.Context, limit *int, page *int) ([]*model.ShoppingList, error) {
+	return r.SM.All(ctx, auth.ForContext(ctx).ID, *limit, *page)
+}
+
+// Pantry is the resolver for the pantry field.
+func (r *queryResolver) Pantry(ctx context.Context, id string) (*model.Pantry, error) {
+	return r.PM.Get(ctx, id)
+}
+
+// Pantries is the resolver for the pantries field.
+func (r *queryResolver) Pantries(ctx context.Context, limit *int, page *int) ([]*model.Pantry, error) {
+	return r.PM.All(ctx, auth.ForContext(ctx).ID, *limit, *page)
+}
+
+// CookableRecipes is the resolver for the cookableRecipes field.
+func (r *queryResolver) CookableRecipes(ctx context.Context, pantryID string, maxMissing *int, expiringDays *int, limit *int) ([]*model.CookableRecipe, error) {
+	return r.cookableRecipes(ctx, pantryID, *maxMissing, *expiringDays, *limit)
+}
+
+// MealPlan is the resolver for the mealPlan field.
+func (r *queryResolver) MealPlan(ctx context.Context, id string) (*model.MealPlan, error) {
+	return r.MP.Get(ctx, id)
+}
+
+// MealPlans is the resolver for the mealPlans field.
+func (r *queryResolver) MealPlans(ctx context.Context, limit *int, page *int) ([]*model.MealPlan, error) {
+	return r.MP.All(ctx, auth.ForContext(ctx).ID, *limit, *page)
+}
+
 // Search is the resolver for the search field.
 func (r *queryResolver) Search(ctx context.Context, query string, limit *int, page *int) ([]model.SearchRecipeResult, error) {
 	var (
@@ -167,11 +818,186 @@ func (r *queryResolver) Search(ctx context.Context, query string, limit *int, pa
 	return res, nil
 }
 
+// RecipesConnection is the resolver for the recipesConnection field.
+func (r *queryResolver) RecipesConnection(ctx context.Context, filter *model.RecipeFilter, raw map[string]interface{}, first *int, after *string) (*model.RecipeConnection, error) {
+	n, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	kind, from, err := decodeCursor(after)
+	if err != nil || (from != nil && kind != recipeCursor) {
+		return nil, apperr.Invalid([]string{"after"}, "invalid cursor")
+	}
+	if filter == nil {
+		filter = &model.RecipeFilter{}
+	}
+	if filter.Raw, err = r.rawFilter(raw); err != nil {
+		return nil, err
+	}
+
+	recipes, more, err := r.RM.AllAfter(ctx, filter, preloads.GetFields(ctx, "edges.node"), n, from)
+	if err != nil {
+		return nil, err
+	}
+	conn := &model.RecipeConnection{Edges: []*model.RecipeEdge{}}
+	var cursors []string
+	for _, recipe := range recipes {
+		cursor := encodeCursor(recipeCursor, recipe.ID)
+		cursors = append(cursors, cursor)
+		conn.Edges = append(conn.Edges, &model.RecipeEdge{Cursor: cursor, Node: recipe})
+	}
+	conn.PageInfo = pageInfo(cursors, after, more)
+	return conn, nil
+}
+
+// IngredientsConnection is the resolver for the ingredientsConnection field.
+func (r *queryResolver) IngredientsConnection(ctx context.Context, filter *model.IngredientFilter, raw map[string]interface{}, first *int, after *string) (*model.IngredientConnection, error) {
+	n, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	kind, from, err := decodeCursor(after)
+	if err != nil || (from != nil && kind != ingredientCursor) {
+		return nil, apperr.Invalid([]string{"after"}, "invalid cursor")
+	}
+	if filter == nil {
+		filter = &model.IngredientFilter{}
+	}
+	if filter.Raw, err = r.rawFilter(raw); err != nil {
+		return nil, err
+	}
+
+	ingredients, more, err := r.IM.AllAfter(ctx, filter, preloads.GetFields(ctx, "edges.node"), n, from)
+	if err != nil {
+		return nil, err
+	}
+	conn := &model.IngredientConnection{Edges: []*model.IngredientEdge{}}
+	var cursors []string
+	for _, ingredient := range ingredients {
+		cursor := encodeCursor(ingredientCursor, ingredient.ID)
+		cursors = append(cursors, cursor)
+		conn.Edges = append(conn.Edges, &model.IngredientEdge{Cursor: cursor, Node: ingredient})
+	}
+	conn.PageInfo = pageInfo(cursors, after, more)
+	return conn, nil
+}
+
+// SearchConnection is the resolver for the searchConnection field.
+func (r *queryResolver) SearchConnection(ctx context.Context, query string, first *int, after *string) (*model.SearchConnection, error) {
+	n, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	kind, from, err := decodeCursor(after)
+	if err != nil {
+		return nil, apperr.Invalid([]string{"after"}, "invalid cursor")
+	}
+
+	conn := &model.SearchConnection{Edges: []*model.SearchRecipeResultEdge{}}
+	var (
+		cursors []string
+		more    bool
+	)
+	// recipes come first, then ingredients; a recipe cursor resumes within
+	// the recipes and an ingredient cursor within the ingredients
+	if kind != ingredientCursor {
+		recipes, rmore, err := r.RM.SearchAfter(ctx, query, n, from)
+		if err != nil {
+			return nil, err
+		}
+		for _, recipe := range recipes {
+			cursor := encodeCursor(recipeCursor, recipe.ID)
+			cursors = append(cursors, cursor)
+			conn.Edges = append(conn.Edges, &model.SearchRecipeResultEdge{Cursor: cursor, Node: recipe})
+		}
+		more = rmore
+		from = nil
+	}
+	if !more {
+		ingredients, imore, err := r.IM.SearchAfter(ctx, query, n-len(conn.Edges), from)
+		if err != nil {
+			return nil, err
+		}
+		for _, ingredient := range ingredients {
+			cursor := encodeCursor(ingredientCursor, ingredient.ID)
+			cursors = append(cursors, cursor)
+			conn.Edges = append(conn.Edges, &model.SearchRecipeResultEdge{Cursor: cursor, Node: ingredient})
+		}
+		more = imore
+	}
+	conn.PageInfo = pageInfo(cursors, after, more)
+	return conn, nil
+}
+
+// ParseIngredientLine is the resolver for the parseIngredientLine field.
+func (r *queryResolver) ParseIngredientLine(ctx context.Context, text string) (*model.IngredientLine, error) {
+	return ingredientLine(text), nil
+}
+
 // ID is the resolver for the id field.
 func (r *recipeResolver) ID(ctx context.Context, obj *model.Recipe) (string, error) {
 	return obj.ID.Hex(), nil
 }
 
+// Timers is the resolver for the timers field.
+func (r *recipeResolver) Timers(ctx context.Context, obj *model.Recipe) ([]string, error) {
+	var timers []string
+	for _, t := range obj.Timed() {
+		timers = append(timers, t.Text)
+	}
+	return append(timers, model.Untimed(obj.Timers)...), nil
+}
+
+// Timings is the resolver for the timings field.
+func (r *recipeResolver) Timings(ctx context.Context, obj *model.Recipe) ([]*model.Timer, error) {
+	timers := obj.Timed()
+	if timers == nil {
+		timers = []*model.Timer{}
+	}
+	return timers, nil
+}
+
+// Steps is the resolver for the steps field.
+func (r *recipeResolver) Steps(ctx context.Context, obj *model.Recipe) ([]string, error) {
+	var steps []string
+	for _, s := range obj.Structured() {
+		steps = append(steps, s.Text)
+	}
+	return steps, nil
+}
+
+// Instructions is the resolver for the instructions field.
+func (r *recipeResolver) Instructions(ctx context.Context, obj *model.Recipe) ([]*model.Step, error) {
+	steps := obj.Structured()
+	if steps == nil {
+		steps = []*model.Step{}
+	}
+	return steps, nil
+}
+
+// Ingredients is the resolver for the ingredients field.
+func (r *recipeResolver) Ingredients(ctx context.Context, obj *model.Recipe, units *model.UnitSystem) ([]*model.Ingredient, error) {
+	// single recipe reads and mutations return their ingredients, lists
+	// leave them to the loader
+	if obj.Ingredients != nil {
+		return convertIngredients(ctx, obj.Ingredients, units)
+	}
+	ingredients, err := loader.For(ctx).IngredientsByRecipe.Load(ctx, obj.ID)()
+	if err != nil {
+		return nil, err
+	}
+	return convertIngredients(ctx, model.OrderIngredients(obj.IngredientIDs, ingredients), units)
+}
+
+// IngredientGroups is the resolver for the ingredientGroups field.
+func (r *recipeResolver) IngredientGroups(ctx context.Context, obj *model.Recipe, units *model.UnitSystem) ([]*model.IngredientGroup, error) {
+	ingredients, err := r.Ingredients(ctx, obj, units)
+	if err != nil {
+		return nil, err
+	}
+	return model.GroupIngredients(obj.Groups, ingredients), nil
+}
+
 // IngredientIDS is the resolver for the ingredientIDS field.
 func (r *recipeResolver) IngredientIDS(ctx context.Context, obj *model.Recipe) ([]string, error) {
 	var ids []string
@@ -181,54 +1007,210 @@ func (r *recipeResolver) IngredientIDS(ctx context.Context, obj *model.Recipe) (
 	return ids, nil
 }
 
+// CreatedAt is the resolver for the createdAt field.
+func (r *recipeResolver) CreatedAt(ctx context.Context, obj *model.Recipe) (*time.Time, error) {
+	created := obj.ID.Timestamp()
+	return &created, nil
+}
+
+// CreatedBy is the resolver for the createdBy field.
+func (r *recipeResolver) CreatedBy(ctx context.Context, obj *model.Recipe) (*model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).UserByID.Load(ctx, *obj.CreatedBy)()
+}
+
+// UpdatedBy is the resolver for the updatedBy field.
+func (r *recipeResolver) UpdatedBy(ctx context.Context, obj *model.Recipe) (*model.User, error) {
+	if obj.UpdatedBy == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).UserByID.Load(ctx, *obj.UpdatedBy)()
+}
+
 // Pagination is the resolver for the pagination field.
 func (r *recipeResolver) Pagination(ctx context.Context, obj *model.Recipe) (*model.PaginationData, error) {
 	return &model.PaginationData{
-		Total:     int(obj.Pagination.Pagination.Total),
-		Page:      int(obj.Pagination.Pagination.Page),
-		PerPage:   int(obj.Pagination.Pagination.PerPage),
-		Prev:      int(obj.Pagination.Pagination.Prev),
-		Next:      int(obj.Pagination.Pagination.Next),
-		TotalPage: int(obj.Pagination.Pagination.TotalPage),
+		Total:     obj.Pagination.Total,
+		Page:      obj.Pagination.Page,
+		PerPage:   obj.Pagination.PerPage,
+		Prev:      obj.Pagination.Prev,
+		Next:      obj.Pagination.Next,
+		TotalPage: obj.Pagination.TotalPage,
 	}, nil
 }
 
-// Recipe is the resolver for the recipe field.
-func (r *subscriptionResolver) Recipe(ctx context.Context) (<-chan []*model.Recipe, error) {
-	id := uuid.UUIDv4()
-	recipes := make(chan []*model.Recipe, 1)
-
-	go func() {
-		<-ctx.Done()
-		r.mu.Lock()
-		delete(r.RecipeObservers, id)
-		r.mu.Unlock()
-	}()
-	r.mu.Lock()
-
-	r.RecipeObservers[id] = recipes
-	r.mu.Unlock()
-	r.RecipeObservers[id] <- r.Recipes
+// ID is the resolver for the id field.
+func (r *shoppingItemResolver) ID(ctx context.Context, obj *model.ShoppingItem) (string, error) {
+	return obj.ID.Hex(), nil
+}
+
+// Food is the resolver for the food field.
+func (r *shoppingItemResolver) Food(ctx context.Context, obj *model.ShoppingItem) (*model.Food, error) {
+	if obj.FoodID == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).FoodByID.Load(ctx, *obj.FoodID)()
+}
+
+// Recipes is the resolver for the recipes field.
+func (r *shoppingItemResolver) Recipes(ctx context.Context, obj *model.ShoppingItem) ([]*model.Recipe, error) {
+	found, errs := loader.For(ctx).RecipeByID.LoadMany(ctx, obj.RecipeIDs)()
+	recipes := []*model.Recipe{}
+	for n, recipe := range found {
+		if errs != nil && errs[n] != nil {
+			return nil, errs[n]
+		}
+		// recipes deleted since the list was saved are left out
+		if recipe != nil {
+			recipes = append(recipes, recipe)
+		}
+	}
 	return recipes, nil
 }
 
+// ID is the resolver for the id field.
+func (r *shoppingListResolver) ID(ctx context.Context, obj *model.ShoppingList) (*string, error) {
+	if obj.ID.IsZero() {
+		return nil, nil
+	}
+	id := obj.ID.Hex()
+	return &id, nil
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *shoppingListResolver) CreatedAt(ctx context.Context, obj *model.ShoppingList) (*time.Time, error) {
+	if obj.ID.IsZero() {
+		return nil, nil
+	}
+	created := obj.ID.Timestamp()
+	return &created, nil
+}
+
+// CreatedBy is the resolver for the createdBy field.
+func (r *shoppingListResolver) CreatedBy(ctx context.Context, obj *model.ShoppingList) (*model.User, error) {
+	if obj.CreatedBy == nil {
+		return nil, nil
+	}
+	return loader.For(ctx).UserByID.Load(ctx, *obj.CreatedBy)()
+}
+
+// Recipe is the resolver for the recipe field.
+func (r *shoppingListRecipeResolver) Recipe(ctx context.Context, obj *model.ShoppingListRecipe) (*model.Recipe, error) {
+	return loader.For(ctx).RecipeByID.Load(ctx, obj.RecipeID)()
+}
+
+// Temperature is the resolver for the temperature field.
+func (r *stepResolver) Temperature(ctx context.Context, obj *model.Step, units *model.UnitSystem) (*string, error) {
+	if obj.Temperature == nil {
+		return nil, nil
+	}
+	t := quantity.Convert(*obj.Temperature, "", unitSystem(ctx, units)).String()
+	return &t, nil
+}
+
+// IngredientIDs is the resolver for the ingredientIDs field.
+func (r *stepResolver) IngredientIDs(ctx context.Context, obj *model.Step) ([]string, error) {
+	ids := []string{}
+	for _, id := range obj.IngredientIDs {
+		ids = append(ids, id.Hex())
+	}
+	return ids, nil
+}
+
+// Recipe is the resolver for the recipe field.
+func (r *subscriptionResolver) Recipe(ctx context.Context) (<-chan *model.RecipeEvent, error) {
+	return r.Broker.Subscribe(ctx)
+}
+
+// ID is the resolver for the id field.
+func (r *userResolver) ID(ctx context.Context, obj *model.User) (string, error) {
+	return obj.ID.Hex(), nil
+}
+
+// Email is the resolver for the email field.
+func (r *userResolver) Email(ctx context.Context, obj *model.User) (*string, error) {
+	if viewer := auth.ForContext(ctx); viewer == nil || (viewer.ID != obj.ID && r.role(viewer) != model.RoleAdmin) {
+		return nil, nil
+	}
+	return &obj.Email, nil
+}
+
+// Role is the resolver for the role field.
+func (r *userResolver) Role(ctx context.Context, obj *model.User) (model.Role, error) {
+	return r.role(obj), nil
+}
+
+// CreatedAt is the resolver for the createdAt field.
+func (r *userResolver) CreatedAt(ctx context.Context, obj *model.User) (*time.Time, error) {
+	created := obj.ID.Timestamp()
+	return &created, nil
+}
+
+// APIKey returns generated.APIKeyResolver implementation.
+func (r *Resolver) APIKey() generated.APIKeyResolver { return &aPIKeyResolver{r} }
+
+// Food returns generated.FoodResolver implementation.
+func (r *Resolver) Food() generated.FoodResolver { return &foodResolver{r} }
+
 // Ingredient returns generated.IngredientResolver implementation.
 func (r *Resolver) Ingredient() generated.IngredientResolver { return &ingredientResolver{r} }
 
+// MealPlan returns generated.MealPlanResolver implementation.
+func (r *Resolver) MealPlan() generated.MealPlanResolver { return &mealPlanResolver{r} }
+
+// MealPlanEntry returns generated.MealPlanEntryResolver implementation.
+func (r *Resolver) MealPlanEntry() generated.MealPlanEntryResolver { return &mealPlanEntryResolver{r} }
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
+// Pantry returns generated.PantryResolver implementation.
+func (r *Resolver) Pantry() generated.PantryResolver { return &pantryResolver{r} }
+
+// PantryItem returns generated.PantryItemResolver implementation.
+func (r *Resolver) PantryItem() generated.PantryItemResolver { return &pantryItemResolver{r} }
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
 // Recipe returns generated.RecipeResolver implementation.
 func (r *Resolver) Recipe() generated.RecipeResolver { return &recipeResolver{r} }
 
+// ShoppingItem returns generated.ShoppingItemResolver implementation.
+func (r *Resolver) ShoppingItem() generated.ShoppingItemResolver { return &shoppingItemResolver{r} }
+
+// ShoppingList returns generated.ShoppingListResolver implementation.
+func (r *Resolver) ShoppingList() generated.ShoppingListResolver { return &shoppingListResolver{r} }
+
+// ShoppingListRecipe returns generated.ShoppingListRecipeResolver implementation.
+func (r *Resolver) ShoppingListRecipe() generated.ShoppingListRecipeResolver {
+	return &shoppingListRecipeResolver{r}
+}
+
+// Step returns generated.StepResolver implementation.
+func (r *Resolver) Step() generated.StepResolver { return &stepResolver{r} }
+
 // Subscription returns generated.SubscriptionResolver implementation.
 func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
 
+// User returns generated.UserResolver implementation.
+func (r *Resolver) User() generated.UserResolver { return &userResolver{r} }
+
+type aPIKeyResolver struct{ *Resolver }
+type foodResolver struct{ *Resolver }
 type ingredientResolver struct{ *Resolver }
+type mealPlanResolver struct{ *Resolver }
+type mealPlanEntryResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type pantryResolver struct{ *Resolver }
+type pantryItemResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type recipeResolver struct{ *Resolver }
+type shoppingItemResolver struct{ *Resolver }
+type shoppingListResolver struct{ *Resolver }
+type shoppingListRecipeResolver struct{ *Resolver }
+type stepResolver struct{ *Resolver }
 type subscriptionResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
@@ -0,0 +1,135 @@
+package graph
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"github.com/ottolauncher/recipes/utils/text"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// shoppingList builds the list for recipeIDs, each scaled to the servings
+// at the same index when there are some: one item per food, with the
+// quantities of its lines added up in units. Lines that point to no food
+// are merged by name. path is where the arguments sit in the request, for
+// the errors.
+func (r *Resolver) shoppingList(ctx context.Context, path []string, recipeIDs []string, servings []*int, units *model.UnitSystem) (*model.ShoppingList, error) {
+	if len(recipeIDs) == 0 {
+		return nil, apperr.Invalid(at(path, "recipeIDs"), "at least one recipe is required")
+	}
+	if servings != nil && len(servings) != len(recipeIDs) {
+		return nil, apperr.Invalid(at(path, "servings"), "servings must have one entry per recipe")
+	}
+	ids := make([]primitive.ObjectID, len(recipeIDs))
+	for n, id := range recipeIDs {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, apperr.Invalid(at(path, "recipeIDs", strconv.Itoa(n)), "invalid id %q", id)
+		}
+		ids[n] = oid
+	}
+
+	found, err := r.RM.ByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	recipes := make(map[primitive.ObjectID]*model.Recipe, len(found))
+	for _, recipe := range found {
+		recipes[recipe.ID] = recipe
+	}
+	lines, err := r.IM.ByRecipes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byRecipe := make(map[primitive.ObjectID][]*model.Ingredient, len(ids))
+	for _, i := range lines {
+		byRecipe[i.RecipeID] = append(byRecipe[i.RecipeID], i)
+	}
+
+	list := &model.ShoppingList{Units: resolveUnits(ctx, units), Recipes: []*model.ShoppingListRecipe{}, Items: []*model.ShoppingItem{}}
+	type merged struct {
+		item   *model.ShoppingItem
+		amount []quantity.Quantity
+	}
+	var items []*merged
+	byKey := map[string]*merged{}
+	for n, id := range ids {
+		recipe, ok := recipes[id]
+		if !ok {
+			return nil, &apperr.Error{Code: apperr.NotFound, Message: "recipe " + id.Hex() + " not found", Field: at(path, "recipeIDs", strconv.Itoa(n))}
+		}
+		entry := &model.ShoppingListRecipe{RecipeID: id}
+		joined := *recipe
+		joined.Ingredients = byRecipe[id]
+		if servings != nil && servings[n] != nil {
+			scaled, err := scaleRecipe(&joined, *servings[n])
+			if err != nil {
+				e := *apperr.From(err)
+				e.Field = at(path, "servings", strconv.Itoa(n))
+				return nil, &e
+			}
+			joined, entry.Servings = *scaled, servings[n]
+		}
+		list.Recipes = append(list.Recipes, entry)
+
+		for _, i := range joined.Ingredients {
+			key := "name:" + text.Slugify(i.Name)
+			if i.FoodID != nil {
+				key = i.FoodID.Hex()
+			}
+			m := byKey[key]
+			if m == nil {
+				m = &merged{item: &model.ShoppingItem{ID: primitive.NewObjectID(), FoodID: i.FoodID, Name: i.Name, RecipeIDs: []primitive.ObjectID{}}}
+				byKey[key] = m
+				items = append(items, m)
+			}
+			m.amount = append(m.amount, i.Measured())
+			if !hasID(m.item.RecipeIDs, id) {
+				m.item.RecipeIDs = append(m.item.RecipeIDs, id)
+			}
+		}
+	}
+
+	var foodIDs []primitive.ObjectID
+	for _, m := range items {
+		if m.item.FoodID != nil {
+			foodIDs = append(foodIDs, *m.item.FoodID)
+		}
+	}
+	foods := map[primitive.ObjectID]*model.Food{}
+	if len(foodIDs) > 0 {
+		found, err := r.FM.ByIDs(ctx, foodIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range found {
+			foods[f.ID] = f
+		}
+	}
+
+	system := unitSystem(ctx, &list.Units)
+	for _, m := range items {
+		var food *model.Food
+		if m.item.FoodID != nil {
+			food = foods[*m.item.FoodID]
+		}
+		if food != nil {
+			m.item.Name, m.item.Category = food.Name, food.Category
+		}
+		m.item.Quantities = quantity.Sum(m.amount, density(m.item.Name, food), system)
+		list.Items = append(list.Items, m.item)
+	}
+	return list, nil
+}
+
+func hasID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/ottolauncher/recipes/graph/loader"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type unitsKey struct{}
+
+// Units picks the units an operation shows quantities in when a field does
+// not ask for any: the X-Units header (METRIC, US or ORIGINAL) if the client
+// sent one, else the region of its preferred language, else def.
+func Units(def model.UnitSystem) graphql.OperationMiddleware {
+	return func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		units := def
+		headers := graphql.GetOperationContext(ctx).Headers
+		if u := model.UnitSystem(strings.ToUpper(strings.TrimSpace(headers.Get("X-Units")))); u.IsValid() {
+			units = u
+		} else if u, ok := languageUnits(headers.Get("Accept-Language")); ok {
+			units = u
+		}
+		return next(context.WithValue(ctx, unitsKey{}, units))
+	}
+}
+
+// languageUnits maps the first language of an Accept-Language header to US
+// units for the United States and to metric ones for any other region.
+// Languages without a region, such as "en", say nothing about the kitchen.
+func languageUnits(accept string) (model.UnitSystem, bool) {
+	lang, _, _ := strings.Cut(accept, ",")
+	lang, _, _ = strings.Cut(lang, ";")
+	_, region, ok := strings.Cut(strings.TrimSpace(lang), "-")
+	switch {
+	case !ok || len(region) != 2:
+		return "", false
+	case strings.EqualFold(region, "US"):
+		return model.UnitSystemUs, true
+	}
+	return model.UnitSystemMetric, true
+}
+
+// resolveUnits returns units, or the operation's units when units is nil.
+func resolveUnits(ctx context.Context, units *model.UnitSystem) model.UnitSystem {
+	if units != nil {
+		return *units
+	}
+	if u, ok := ctx.Value(unitsKey{}).(model.UnitSystem); ok {
+		return u
+	}
+	return model.UnitSystemOriginal
+}
+
+// unitSystem is resolveUnits as the quantity package knows it.
+func unitSystem(ctx context.Context, units *model.UnitSystem) quantity.System {
+	switch resolveUnits(ctx, units) {
+	case model.UnitSystemMetric:
+		return quantity.Metric
+	case model.UnitSystemUs:
+		return quantity.US
+	}
+	return quantity.Original
+}
+
+// convertIngredients returns copies of ingredients with their quantities in
+// units, or in the operation's units when units is nil. The density of a
+// line's food, when the catalog has one, wins over the built-in table.
+func convertIngredients(ctx context.Context, ingredients []*model.Ingredient, units *model.UnitSystem) ([]*model.Ingredient, error) {
+	system := unitSystem(ctx, units)
+	if system == quantity.Original {
+		return ingredients, nil
+	}
+
+	var ids []primitive.ObjectID
+	for _, i := range ingredients {
+		if i.FoodID != nil {
+			ids = append(ids, *i.FoodID)
+		}
+	}
+	foods, errs := loader.For(ctx).FoodByID.LoadMany(ctx, ids)()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[primitive.ObjectID]*model.Food, len(foods))
+	for _, f := range foods {
+		if f != nil {
+			byID[f.ID] = f
+		}
+	}
+
+	out := make([]*model.Ingredient, len(ingredients))
+	for n, i := range ingredients {
+		converted := *i
+		var food *model.Food
+		if i.FoodID != nil {
+			food = byID[*i.FoodID]
+		}
+		measure := quantity.ConvertWith(i.Measured(), density(i.Name, food), system)
+		converted.Measure = &measure
+		out[n] = &converted
+	}
+	return out, nil
+}
+
+// density returns the density to convert a line named name with: that of
+// its food when the catalog has one, else the built-in one for the name.
+func density(name string, food *model.Food) *quantity.Density {
+	d, known := quantity.DensityOf(name)
+	if food != nil && food.Density != nil {
+		// the catalog does not say whether a food is weighed; what the table
+		// knows of the name does, and foods are weighed otherwise
+		return &quantity.Density{GPerML: *food.Density, Weighed: !known || d.Weighed}
+	}
+	if known {
+		return &d
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package graph
+
+import (
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/utils/duration"
+	"github.com/ottolauncher/recipes/utils/quantity"
+	"github.com/ottolauncher/recipes/utils/text"
+)
+
+// Input checks shared by both storage backends. Each returns every problem
+// it finds, with the path of the offending field below path.
+
+func validateNewIngredient(path []string, in *model.NewIngredient) []error {
+	var errs []error
+	if strings.TrimSpace(in.Name) == "" {
+		errs = append(errs, apperr.Invalid(at(path, "name"), "name is required"))
+	}
+	return errs
+}
+
+func validateUpdateIngredient(path []string, in *model.UpdateIngredient) []error {
+	if in == nil {
+		return []error{apperr.Invalid(path, "input is required")}
+	}
+	var errs []error
+	if strings.TrimSpace(in.Name) == "" {
+		errs = append(errs, apperr.Invalid(at(path, "name"), "name is required"))
+	}
+	return errs
+}
+
+func validateNewFood(path []string, in *model.NewFood) []error {
+	return validateFood(path, in.Name, in.Density)
+}
+
+func validateUpdateFood(path []string, in *model.UpdateFood) []error {
+	return validateFood(path, in.Name, in.Density)
+}
+
+func validateFood(path []string, name string, density *float64) []error {
+	var errs []error
+	switch {
+	case strings.TrimSpace(name) == "":
+		errs = append(errs, apperr.Invalid(at(path, "name"), "name is required"))
+	case text.Slugify(name) == "":
+		errs = append(errs, apperr.Invalid(at(path, "name"), "name must hold a letter or a digit"))
+	}
+	if density != nil && *density <= 0 {
+		errs = append(errs, apperr.Invalid(at(path, "density"), "density must be more than 0"))
+	}
+	return errs
+}
+
+func validateRegister(path []string, in *model.Register) []error {
+	var errs []error
+	if _, err := mail.ParseAddress(in.Email); err != nil || strings.ContainsAny(in.Email, "<>") {
+		errs = append(errs, apperr.Invalid(at(path, "email"), "%q is not an email address", in.Email))
+	}
+	if strings.TrimSpace(in.Name) == "" {
+		errs = append(errs, apperr.Invalid(at(path, "name"), "name is required"))
+	}
+	if utf8.RuneCountInString(in.Password) < auth.MinPasswordLength {
+		errs = append(errs, apperr.Invalid(at(path, "password"), "password must have at least %d characters", auth.MinPasswordLength))
+	}
+	return errs
+}
+
+func validateNewAPIKey(path []string, in *model.NewAPIKey) []error {
+	var errs []error
+	if strings.TrimSpace(in.Name) == "" {
+		errs = append(errs, apperr.Invalid(at(path, "name"), "name is required"))
+	}
+	if len(in.Scopes) == 0 {
+		errs = append(errs, apperr.Invalid(at(path, "scopes"), "a key needs at least one scope"))
+	}
+	for n, scope := range in.Scopes {
+		known := false
+		for _, s := range model.Scopes {
+			known = known || s == scope
+		}
+		if !known {
+			errs = append(errs, apperr.Invalid(at(path, "scopes", strconv.Itoa(n)), "unknown scope %q, use one of %s", scope, strings.Join(model.Scopes, ", ")))
+		}
+	}
+	return errs
+}
+
+func validateNewPantry(path []string, in *model.NewPantry) []error {
+	return validatePantry(path, in.Name, in.Items)
+}
+
+func validateUpdatePantry(path []string, in *model.UpdatePantry) []error {
+	return validatePantry(path, in.Name, in.Items)
+}
+
+func validatePantry(path []string, name string, items []*model.PantryItemInput) []error {
+	var errs []error
+	if strings.TrimSpace(name) == "" {
+		errs = append(errs, apperr.Invalid(at(path, "name"), "name is required"))
+	}
+	for n, i := range items {
+		item := at(path, "items", strconv.Itoa(n), "name")
+		switch {
+		case strings.TrimSpace(i.Name) == "":
+			errs = append(errs, apperr.Invalid(item, "name is required"))
+		case text.Slugify(i.Name) == "":
+			errs = append(errs, apperr.Invalid(item, "name must hold a letter or a digit"))
+		}
+	}
+	return errs
+}
+
+func validateNewMealPlan(path []string, in *model.NewMealPlan) []error {
+	if strings.TrimSpace(in.Name) == "" {
+		return []error{apperr.Invalid(at(path, "name"), "name is required")}
+	}
+	return nil
+}
+
+func validatePlanMeal(path []string, in *model.PlanMeal) []error {
+	errs := validateDate(at(path, "date"), in.Date)
+	return append(errs, validateServings(at(path, "servings"), in.Servings)...)
+}
+
+func validateMoveMeal(path []string, in *model.MoveMeal) []error {
+	return validateDate(at(path, "date"), in.Date)
+}
+
+func validateDate(path []string, date string) []error {
+	if _, err := time.Parse(model.DateLayout, date); err != nil {
+		return []error{apperr.Invalid(path, "%q is not a date written as YYYY-MM-DD", date)}
+	}
+	return nil
+}
+
+func validateDateRange(path []string, from, to string) []error {
+	errs := append(validateDate(at(path, "from"), from), validateDate(at(path, "to"), to)...)
+	if len(errs) == 0 && to < from {
+		errs = append(errs, apperr.Invalid(at(path, "to"), "to must not be before from"))
+	}
+	return errs
+}
+
+func validateNewRecipe(path []string, in *model.NewRecipe) []error {
+	var errs []error
+	if strings.TrimSpace(in.Name) == "" {
+		errs = append(errs, apperr.Invalid(at(path, "name"), "name is required"))
+	}
+	errs = append(errs, validateURL(at(path, "originalURL"), in.OriginalURL)...)
+	errs = append(errs, validateServings(at(path, "servings"), in.Servings)...)
+	errs = append(errs, validateSteps(path, in.Instructions, len(in.Lines()))...)
+	errs = append(errs, validateTimers(path, in.Timings, len(in.Steps)+len(in.Instructions))...)
+	errs = append(errs, validateGroups(path, in.GroupNames())...)
+	for n, i := range in.Ingredients {
+		errs = append(errs, validateNewIngredient(at(path, "ingredients", strconv.Itoa(n)), i)...)
+	}
+	for g, group := range in.IngredientGroups {
+		for n, i := range group.Ingredients {
+			errs = append(errs, validateNewIngredient(at(path, "ingredientGroups", strconv.Itoa(g), "ingredients", strconv.Itoa(n)), i)...)
+		}
+	}
+	return errs
+}
+
+func validateUpdateRecipe(path []string, in *model.UpdateRecipe) []error {
+	var errs []error
+	if strings.TrimSpace(in.Name) == "" {
+		errs = append(errs, apperr.Invalid(at(path, "name"), "name is required"))
+	}
+	errs = append(errs, validateURL(at(path, "originalURL"), in.OriginalURL)...)
+	errs = append(errs, validateServings(at(path, "servings"), in.Servings)...)
+	errs = append(errs, validateSteps(path, in.Instructions, len(in.Lines()))...)
+	errs = append(errs, validateTimers(path, in.Timings, len(in.Steps)+len(in.Instructions))...)
+	errs = append(errs, validateGroups(path, in.GroupNames())...)
+	for n, i := range in.Ingredients {
+		if strings.TrimSpace(i.Name) == "" {
+			errs = append(errs, apperr.Invalid(at(path, "ingredients", strconv.Itoa(n), "name"), "name is required"))
+		}
+	}
+	for g, group := range in.IngredientGroups {
+		for n, i := range group.Ingredients {
+			if strings.TrimSpace(i.Name) == "" {
+				errs = append(errs, apperr.Invalid(at(path, "ingredientGroups", strconv.Itoa(g), "ingredients", strconv.Itoa(n), "name"), "name is required"))
+			}
+		}
+	}
+	return errs
+}
+
+// validateURL checks that raw is an absolute http or https URL. Recipe
+// URLs end up in calendar feeds and links other users follow.
+func validateURL(path []string, raw string) []error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return []error{apperr.Invalid(path, "%q is not an http or https URL", raw)}
+	}
+	return nil
+}
+
+func validateServings(path []string, servings *int) []error {
+	if servings != nil && *servings < 1 {
+		return []error{apperr.Invalid(path, "servings must be at least 1")}
+	}
+	return nil
+}
+
+// validateGroups checks that the ingredient groups of a recipe have
+// distinct, non-empty names.
+func validateGroups(path []string, names []string) []error {
+	var errs []error
+	seen := map[string]bool{}
+	for n, name := range names {
+		p := at(path, "ingredientGroups", strconv.Itoa(n), "name")
+		switch {
+		case strings.TrimSpace(name) == "":
+			errs = append(errs, apperr.Invalid(p, "name is required"))
+		case seen[name]:
+			errs = append(errs, apperr.Invalid(p, "there is already a group named %q", name))
+		}
+		seen[name] = true
+	}
+	return errs
+}
+
+// validateSteps checks the instructions of a recipe with the given number
+// of ingredient lines.
+func validateSteps(path []string, instructions []*model.StepInput, ingredients int) []error {
+	var errs []error
+	for n, s := range instructions {
+		p := at(path, "instructions", strconv.Itoa(n))
+		if strings.TrimSpace(s.Text) == "" {
+			errs = append(errs, apperr.Invalid(at(p, "text"), "text is required"))
+		}
+		if s.Duration != nil {
+			if _, err := duration.Parse(*s.Duration); err != nil {
+				errs = append(errs, apperr.Invalid(at(p, "duration"), "%v", err))
+			}
+		}
+		if s.Temperature != nil {
+			if t := quantity.Parse(*s.Temperature); t.Amount == nil || (t.Unit != "°C" && t.Unit != "°F") {
+				errs = append(errs, apperr.Invalid(at(p, "temperature"), "temperature must be in °C or °F, such as 180 °C"))
+			}
+		}
+		for k, i := range s.Ingredients {
+			if i < 0 || i >= ingredients {
+				errs = append(errs, apperr.Invalid(at(p, "ingredients", strconv.Itoa(k)), "no ingredient line at index %d", i))
+			}
+		}
+	}
+	return errs
+}
+
+// validateTimers checks that the duration of every timing parses and that
+// timings point at one of the steps. The deprecated timers are free text,
+// kept as written when they are no duration, so they are not checked.
+func validateTimers(path []string, timings []*model.TimerInput, steps int) []error {
+	var errs []error
+	for n, t := range timings {
+		if _, err := duration.Parse(t.Duration); err != nil {
+			errs = append(errs, apperr.Invalid(at(path, "timings", strconv.Itoa(n), "duration"), "%v", err))
+		}
+		switch {
+		case t.Step == nil:
+		case steps == 0:
+			errs = append(errs, apperr.Invalid(at(path, "timings", strconv.Itoa(n), "step"), "the recipe has no steps to link to"))
+		case *t.Step < 0 || *t.Step >= steps:
+			errs = append(errs, apperr.Invalid(at(path, "timings", strconv.Itoa(n), "step"), "step must be between 0 and %d", steps-1))
+		}
+	}
+	return errs
+}
+
+func at(path []string, elems ...string) []string {
+	return append(append([]string{}, path...), elems...)
+}
@@ -2,6 +2,7 @@ package preloads
 
 import (
 	"context"
+	"strings"
 
 	"github.com/99designs/gqlgen/graphql"
 )
@@ -24,6 +25,26 @@ func GetNestedPreloads(ctx *graphql.OperationContext, fields []graphql.Collected
 	return
 }
 
+// GetFields returns the fields selected directly below path in the current
+// field, such as "edges.node" for a connection. An empty path gives the
+// fields of the current field itself. The slice is empty, never nil, when
+// nothing is selected there, since readers take nil for every field.
+func GetFields(ctx context.Context, path string) []string {
+	fields := []string{}
+	for _, p := range GetPreloads(ctx) {
+		if path != "" {
+			if !strings.HasPrefix(p, path+".") {
+				continue
+			}
+			p = strings.TrimPrefix(p, path+".")
+		}
+		if !strings.Contains(p, ".") {
+			fields = append(fields, p)
+		}
+	}
+	return fields
+}
+
 // GetPreloadString collect whished returning field on query
 func GetPreloadString(prefix string, name string) string {
 	if len(prefix) > 0 {
@@ -1,35 +1,48 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
-	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
+	"github.com/ottolauncher/recipes/config"
 	"github.com/ottolauncher/recipes/graph"
+	"github.com/ottolauncher/recipes/graph/apperr"
+	"github.com/ottolauncher/recipes/graph/auth"
+	"github.com/ottolauncher/recipes/graph/db/memory"
 	db "github.com/ottolauncher/recipes/graph/db/mongo"
 	"github.com/ottolauncher/recipes/graph/generated"
+	"github.com/ottolauncher/recipes/graph/loader"
 	"github.com/ottolauncher/recipes/graph/model"
+	"github.com/ottolauncher/recipes/graph/pubsub"
 	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 )
 
-const defaultPort = "8080"
-
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	cfg, opts, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.PrintConfig {
+		fmt.Print(cfg)
+		return
 	}
+	log.Printf("effective configuration:\n%s", cfg)
 
 	e := echo.New()
 	e.Use(middleware.Logger())
@@ -38,37 +51,120 @@ func main() {
 	// 	TokenLookup: "header:X-XSRF-TOKEN",
 	// }))
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
+		AllowOrigins: cfg.CORS.AllowOrigins,
 		AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
 	}))
 
+	var broker pubsub.Broker
+	switch cfg.Broker.Driver {
+	case "redis":
+		broker, err = pubsub.NewRedisBroker(context.Background(), &redis.Options{
+			Addr:     cfg.Broker.RedisAddr,
+			Password: cfg.Broker.RedisPassword,
+			DB:       cfg.Broker.RedisDB,
+		}, cfg.Broker.Channel)
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		broker = pubsub.NewMemoryBroker()
+	}
+	defer broker.Close()
+
 	var (
-		once sync.Once
-		dao  *mongo.Client
+		rm      db.IRecipe
+		im      db.Ingredient
+		fm      db.Food
+		sm      db.ShoppingList
+		pm      db.Pantry
+		mp      db.MealPlan
+		um      db.User
+		ak      db.APIKey
+		watcher *db.Watcher
 	)
 
-	once.Do(func() {
-		dao = db.Init()
-	})
+	switch cfg.Database.Driver {
+	case "memory":
+		store := memory.NewStore()
+		rm = memory.NewRecipeManager(store)
+		im = memory.NewIngredientManager(store)
+		fm = memory.NewFoodManager(store)
+		sm = memory.NewShoppingListManager(store)
+		pm = memory.NewPantryManager(store)
+		mp = memory.NewMealPlanManager(store)
+		um = memory.NewUserManager(store)
+		ak = memory.NewAPIKeyManager(store)
+		log.Println("Using in-memory storage")
+	default:
+		var (
+			once sync.Once
+			dao  *mongo.Client
+		)
+
+		once.Do(func() {
+			dao = db.Init(cfg.Database.URI, cfg.Database.ConnectTimeout)
+		})
+
+		src := dao.Database(cfg.Database.Name)
+		if err := db.EnsureIndexes(context.Background(), src); err != nil {
+			log.Fatal(err)
+		}
 
-	src := dao.Database("recipedb")
+		defer func() {
+			if err := dao.Disconnect(context.TODO()); err != nil {
+				log.Fatal(err)
+			}
+		}()
+
+		t := db.Timeouts{
+			Write: cfg.Database.WriteTimeout,
+			Read:  cfg.Database.ReadTimeout,
+			Query: cfg.Database.QueryTimeout,
+			List:  cfg.Database.ListTimeout,
+		}
+		recipes := db.NewRecipeManager(src, t)
+		ingredients := db.NewIngredientManager(src, t)
+		rm, im, fm = recipes, ingredients, db.NewFoodManager(src, t)
+		sm, pm = db.NewShoppingListManager(src, t), db.NewPantryManager(src, t)
+		mp, um = db.NewMealPlanManager(src, t), db.NewUserManager(src, t)
+		ak = db.NewAPIKeyManager(src, t)
+		if cfg.Database.Watch {
+			watcher = db.NewWatcher(recipes, ingredients, broker)
+		}
+	}
+
+	if watcher != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go watcher.Run(ctx)
+		log.Println("Publishing subscription events from MongoDB change streams")
+	}
 
-	defer func() {
-		if err := dao.Disconnect(context.TODO()); err != nil {
+	secret := []byte(cfg.Auth.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
 			log.Fatal(err)
 		}
-	}()
-
-	rm := db.NewRecipeManager(src)
-	im := db.NewIngredientManager(src)
+		log.Println("No auth.secret set, login tokens will not survive a restart")
+	}
+	issuer := &auth.Issuer{Secret: secret, TTL: cfg.Auth.TokenTTL}
+	e.Use(issuer.Middleware(um))
+	// partner applications call the API with keys instead of user tokens
+	e.Use(auth.KeyMiddleware(ak))
 
-	config := generated.Config{Resolvers: &graph.Resolver{RM: rm, IM: im, Recipes: []*model.Recipe{}, RecipeObservers: map[string]chan []*model.Recipe{}}}
+	resolver := &graph.Resolver{RM: rm, IM: im, FM: fm, SM: sm, PM: pm, MP: mp, UM: um, AK: ak, Auth: issuer, Admins: cfg.Admin.Users, Broker: broker, ChangeStream: watcher != nil, AllowRawFilters: cfg.Admin.RawFilters}
+	config := generated.Config{Resolvers: resolver, Directives: resolver.Directives()}
 
 	srv := handler.NewDefaultServer(generated.NewExecutableSchema(config))
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.AroundOperations(loader.Middleware(rm, im, fm, um))
+	srv.AroundOperations(graph.Units(model.UnitSystem(strings.ToUpper(cfg.Units.Default))))
+	srv.AroundFields(graph.KeyFields)
 
 	srv.AddTransport(transport.POST{})
 	srv.AddTransport(transport.Websocket{
-		KeepAlivePingInterval: 10 * time.Second,
+		KeepAlivePingInterval: cfg.Websocket.KeepAlivePingInterval,
 		Upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -81,22 +177,40 @@ func main() {
 		return nil
 	})
 
-	e.POST("/query", func(c echo.Context) error {
+	query := func(c echo.Context) error {
 		srv.ServeHTTP(c.Response(), c.Request())
 		return nil
+	}
+	// websocket subscriptions upgrade through GET
+	e.GET("/query", query)
+	e.POST("/query", query)
+
+	// calendar applications subscribe to meal plans over plain GET
+	e.GET(graph.CalendarPath(":id", ":token"), func(c echo.Context) error {
+		var feed bytes.Buffer
+		if err := resolver.WriteCalendar(c.Request().Context(), c.Param("id"), c.Param("token"), &feed); err != nil {
+			switch e := apperr.From(err); e.Code {
+			case apperr.NotFound:
+				return echo.NewHTTPError(http.StatusNotFound, "meal plan not found")
+			case apperr.Validation:
+				return echo.NewHTTPError(http.StatusBadRequest, e.Message)
+			}
+			return err
+		}
+		return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", feed.Bytes())
 	})
 	h2s := &http2.Server{
-		MaxConcurrentStreams: 250,
-		MaxReadFrameSize:     1048576,
-		IdleTimeout:          10 * time.Second,
+		MaxConcurrentStreams: cfg.HTTP2.MaxConcurrentStreams,
+		MaxReadFrameSize:     cfg.HTTP2.MaxReadFrameSize,
+		IdleTimeout:          cfg.HTTP2.IdleTimeout,
 	}
 
 	s := http.Server{
-		Addr:    ":" + port,
+		Addr:    ":" + cfg.Port,
 		Handler: h2c.NewHandler(e, h2s),
 	}
 
-	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
+	log.Printf("connect to http://localhost:%s/ for GraphQL playground", cfg.Port)
 	if err := s.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatal(err)
 	}
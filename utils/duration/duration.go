@@ -0,0 +1,175 @@
+// Package duration reads the lengths of recipe timers, written either as
+// ISO 8601 durations such as "PT1H30M" or as phrases such as "1 hr 30 min",
+// and writes them back in ISO 8601.
+package duration
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ottolauncher/recipes/utils/numeral"
+)
+
+const day = 24 * time.Hour
+
+// units maps the spellings phrases may use to the length of one unit.
+var units = map[string]time.Duration{
+	"d": day, "day": day, "days": day,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+}
+
+// fillers may appear between the parts of a phrase, as in "1 hour and
+// 30 minutes" or "about 20 min".
+var fillers = map[string]bool{"and": true, "plus": true, "about": true, "around": true, "approx": true, "approximately": true}
+
+var (
+	number = `\d+\s+\d+/\d+|\d+/\d+|\d+(?:[.,]\d+)?`
+	isoRe  = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)W)?(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+	partRe = regexp.MustCompile(`(` + number + `)(?:\s*(?:-|to)\s*(` + number + `))?\s*(` + alternatives() + `)\b`)
+	// "1h30", where the minutes go without a unit
+	shortRe = regexp.MustCompile(`^(\d+)\s*h\s*(\d{1,2})$`)
+)
+
+func alternatives() string {
+	spellings := make([]string, 0, len(units))
+	for s := range units {
+		spellings = append(spellings, s)
+	}
+	// longest first, so "min" is not read as "m"
+	sort.Slice(spellings, func(a, b int) bool {
+		if len(spellings[a]) != len(spellings[b]) {
+			return len(spellings[a]) > len(spellings[b])
+		}
+		return spellings[a] < spellings[b]
+	})
+	return strings.Join(spellings, "|")
+}
+
+// Parse reads an ISO 8601 duration, "PT1H30M", or a phrase made of amounts
+// and units, "1 hr 30 min", "1½ hours" or "20-25 minutes". A range counts
+// as its upper bound, the time to plan for. Durations longer than a
+// time.Duration holds are an error.
+func Parse(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("duration is empty")
+	}
+	if m := isoRe.FindStringSubmatch(strings.ToUpper(s)); m != nil {
+		return parseISO(s, m)
+	}
+	return parsePhrase(s)
+}
+
+func parseISO(s string, m []string) (time.Duration, error) {
+	var (
+		d     time.Duration
+		found bool
+	)
+	for n, unit := range []time.Duration{7 * day, day, time.Hour, time.Minute, time.Second} {
+		if m[n+1] == "" {
+			continue
+		}
+		v, _ := strconv.ParseFloat(m[n+1], 64)
+		var ok bool
+		if d, ok = add(d, v, unit); !ok {
+			return 0, fmt.Errorf("%q is too long", s)
+		}
+		found = true
+	}
+	// "P" and "PT" match the pattern but say nothing
+	if !found || strings.HasSuffix(s, "T") || strings.HasSuffix(s, "t") {
+		return 0, fmt.Errorf("%q is not a duration", s)
+	}
+	return d, nil
+}
+
+func parsePhrase(s string) (time.Duration, error) {
+	text := strings.ToLower(s)
+	text = strings.NewReplacer("½", " 1/2", "¼", " 1/4", "¾", " 3/4", "–", "-", "—", "-").Replace(text)
+	text = strings.Join(strings.Fields(text), " ")
+	if strings.HasPrefix(text, "an ") || strings.HasPrefix(text, "a ") {
+		_, rest, _ := strings.Cut(text, " ")
+		text = "1 " + rest
+	}
+
+	if m := shortRe.FindStringSubmatch(text); m != nil {
+		h, _ := strconv.ParseFloat(m[1], 64)
+		min, _ := strconv.Atoi(m[2])
+		d, ok := add(time.Duration(min)*time.Minute, h, time.Hour)
+		if !ok {
+			return 0, fmt.Errorf("%q is too long", s)
+		}
+		return d, nil
+	}
+
+	var (
+		d       time.Duration
+		found   bool
+		tooLong bool
+	)
+	rest := partRe.ReplaceAllStringFunc(text, func(part string) string {
+		m := partRe.FindStringSubmatch(part)
+		v, ok := numeral.Parse(m[1])
+		if max, isRange := numeral.Parse(m[2]); isRange {
+			v = max
+		}
+		if ok {
+			if d, ok = add(d, v, units[m[3]]); !ok {
+				tooLong = true
+			}
+			found = true
+		}
+		return " "
+	})
+	if tooLong {
+		return 0, fmt.Errorf("%q is too long", s)
+	}
+	for _, word := range strings.FieldsFunc(rest, func(r rune) bool { return r == ' ' || r == ',' || r == '&' }) {
+		if !fillers[word] {
+			return 0, fmt.Errorf("%q is not a duration", s)
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("%q is not a duration", s)
+	}
+	return d, nil
+}
+
+// add returns d plus v units, and false when the sum does not fit in a
+// Duration.
+func add(d time.Duration, v float64, unit time.Duration) (time.Duration, bool) {
+	sum := float64(d) + v*float64(unit)
+	// float64(math.MaxInt64) rounds up to 2⁶³, which no longer fits
+	if sum >= float64(math.MaxInt64) {
+		return 0, false
+	}
+	return time.Duration(sum), true
+}
+
+// ISO writes d as an ISO 8601 duration in hours, minutes and seconds, the
+// form schema.org recipes use: "PT1H30M", "PT36H", "PT0S".
+func ISO(d time.Duration) string {
+	seconds := int64(math.Round(d.Seconds()))
+	if seconds <= 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	b.WriteString("PT")
+	if h := seconds / 3600; h > 0 {
+		fmt.Fprintf(&b, "%dH", h)
+	}
+	if m := seconds % 3600 / 60; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+	if s := seconds % 60; s > 0 {
+		fmt.Fprintf(&b, "%dS", s)
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package duration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Duration
+	}{
+		{"PT1H30M", 90 * time.Minute},
+		{"pt45m", 45 * time.Minute},
+		{"P1D", 24 * time.Hour},
+		{"P1W", 7 * 24 * time.Hour},
+		{"PT0.5H", 30 * time.Minute},
+		{"1 hr 30 min", 90 * time.Minute},
+		{"1 hour and 30 minutes", 90 * time.Minute},
+		{"1½ hours", 90 * time.Minute},
+		{"20-25 minutes", 25 * time.Minute},
+		{"20 to 25 min", 25 * time.Minute},
+		{"about 20 min", 20 * time.Minute},
+		{"an hour", time.Hour},
+		{"1h30", 90 * time.Minute},
+		{"2 days", 48 * time.Hour},
+		{"45 secs", 45 * time.Second},
+		{"1,5 hours", 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.text)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v, want %v", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, text := range []string{"", "P", "PT", "overnight", "30", "until golden", "5 minutes or so", "P99999999999W", "PT3000000H", "99999999999 days", "9999999h30"} {
+		if got, err := Parse(text); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", text, got)
+		}
+	}
+}
+
+func TestISO(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "PT0S"},
+		{-time.Minute, "PT0S"},
+		{90 * time.Minute, "PT1H30M"},
+		{36 * time.Hour, "PT36H"},
+		{time.Hour + 5*time.Second, "PT1H5S"},
+		{1500 * time.Millisecond, "PT2S"},
+	}
+	for _, tt := range tests {
+		if got := ISO(tt.d); got != tt.want {
+			t.Errorf("ISO(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Package ical writes iCalendar (RFC 5545) feeds of events, which calendar
+// applications can subscribe to.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event is a VEVENT. Start and End are written as floating times, so the
+// calendar shows them at the same hour in any time zone.
+type Event struct {
+	// UID stays the same across feeds so that updates replace the event.
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+const (
+	floating = "20060102T150405"
+	stamp    = "20060102T150405Z"
+	// lineLength is the most octets a content line may hold before it is
+	// folded.
+	lineLength = 75
+)
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// Write writes a calendar named name holding events to w.
+func Write(w io.Writer, name string, events []Event) error {
+	b := bufio.NewWriter(w)
+	now := time.Now().UTC().Format(stamp)
+	line(b, "BEGIN:VCALENDAR")
+	line(b, "VERSION:2.0")
+	line(b, "PRODID:-//ottolauncher//recipes//EN")
+	line(b, "CALSCALE:GREGORIAN")
+	line(b, "X-WR-CALNAME:"+escaper.Replace(name))
+	for _, e := range events {
+		line(b, "BEGIN:VEVENT")
+		line(b, "UID:"+escaper.Replace(e.UID))
+		line(b, "DTSTAMP:"+now)
+		line(b, "DTSTART:"+e.Start.Format(floating))
+		line(b, "DTEND:"+e.End.Format(floating))
+		line(b, "SUMMARY:"+escaper.Replace(e.Summary))
+		if e.Description != "" {
+			line(b, "DESCRIPTION:"+escaper.Replace(e.Description))
+		}
+		if e.URL != "" {
+			line(b, "URL:"+e.URL)
+		}
+		line(b, "END:VEVENT")
+	}
+	line(b, "END:VCALENDAR")
+	return b.Flush()
+}
+
+// line writes s ended by CRLF, folding it into continuation lines that
+// start with a space wherever it runs over lineLength octets. Runes are
+// never split. Control characters other than tabs are dropped, so that no
+// text can end the line early and add lines of its own.
+func line(b *bufio.Writer, s string) {
+	s = strings.Map(func(r rune) rune {
+		if (r < ' ' && r != '\t') || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+	limit := lineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// the leading space counts toward the length
+		limit = lineLength - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
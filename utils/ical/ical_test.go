@@ -0,0 +1,109 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2026, 3, 14, 19, 0, 0, 0, time.UTC)
+	var b bytes.Buffer
+	err := Write(&b, "Week 11", []Event{{
+		UID:         "abc@recipes",
+		Start:       start,
+		End:         start.Add(45 * time.Minute),
+		Summary:     "Dinner: Soup, bread; cheese",
+		Description: "4 servings\nfor guests",
+		URL:         "https://example.com/soup",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Week 11\r\n",
+		"UID:abc@recipes\r\n",
+		"DTSTART:20260314T190000\r\n",
+		"DTEND:20260314T194500\r\n",
+		`SUMMARY:Dinner: Soup\, bread\; cheese` + "\r\n",
+		`DESCRIPTION:4 servings\nfor guests` + "\r\n",
+		"URL:https://example.com/soup\r\n",
+		"END:VEVENT\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Errorf("feed does not end with END:VCALENDAR:\n%s", out)
+	}
+	if strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") {
+		t.Errorf("feed has a bare line feed:\n%s", out)
+	}
+}
+
+func TestWriteLeavesOutEmptyFields(t *testing.T) {
+	var b bytes.Buffer
+	start := time.Date(2026, 3, 14, 8, 0, 0, 0, time.UTC)
+	if err := Write(&b, "Plan", []Event{{UID: "x", Start: start, End: start.Add(time.Hour), Summary: "Breakfast"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"DESCRIPTION:", "URL:"} {
+		if strings.Contains(b.String(), field) {
+			t.Errorf("empty %s written:\n%s", field, b.String())
+		}
+	}
+}
+
+func TestWriteDropsControlCharacters(t *testing.T) {
+	var b bytes.Buffer
+	start := time.Date(2026, 3, 14, 8, 0, 0, 0, time.UTC)
+	url := "https://example.com/soup\r\nEND:VEVENT\r\nBEGIN:VEVENT\nSUMMARY:Injected"
+	if err := Write(&b, "Plan", []Event{{UID: "x", Start: start, End: start.Add(time.Hour), Summary: "Breakfast", URL: url}}); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	if n := strings.Count(out, "\r\nBEGIN:VEVENT\r\n"); n != 1 {
+		t.Errorf("feed has %d events, want 1:\n%s", n, out)
+	}
+	if !strings.Contains(out, "URL:https://example.com/soupEND:VEVENTBEGIN:VEVENTSUMMARY:Injected\r\n") {
+		t.Errorf("URL not written on one line:\n%s", out)
+	}
+}
+
+func TestFolding(t *testing.T) {
+	tests := []string{
+		strings.Repeat("a", 200),
+		strings.Repeat("é", 100),
+		strings.Repeat("ab€", 40),
+	}
+	for _, summary := range tests {
+		var b bytes.Buffer
+		start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		if err := Write(&b, "Plan", []Event{{UID: "x", Start: start, End: start, Summary: summary}}); err != nil {
+			t.Fatal(err)
+		}
+		var unfolded string
+		for n, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+			if len(line) > lineLength {
+				t.Errorf("line %d is %d octets long: %q", n, len(line), line)
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("line %d splits a rune: %q", n, line)
+			}
+			if strings.HasPrefix(line, " ") {
+				unfolded += line[1:]
+			} else {
+				unfolded += "\n" + line
+			}
+		}
+		if !strings.Contains(unfolded, "\nSUMMARY:"+summary+"\n") {
+			t.Errorf("summary %q does not unfold back", summary)
+		}
+	}
+}
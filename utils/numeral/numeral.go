@@ -0,0 +1,46 @@
+// Package numeral reads the numbers recipes are written with: integers,
+// decimals with a point or a comma, fractions and mixed numbers.
+package numeral
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Grouped matches a number grouping its thousands with commas, "1,000" or
+// "1,250.5". Parse reads the commas of such numbers as separators rather
+// than as a decimal comma.
+const Grouped = `\d{1,3}(?:,\d{3})+(?:\.\d+)?\b`
+
+var groupedRe = regexp.MustCompile(`^` + Grouped + `$`)
+
+// Parse reads an integer, a decimal with a point or comma, a number
+// grouping its thousands with commas, a fraction or a mixed number such as
+// "2 1/2". It reports false for anything else.
+func Parse(s string) (float64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	var total float64
+	for _, part := range strings.Fields(s) {
+		if num, den, ok := strings.Cut(part, "/"); ok {
+			n, err1 := strconv.ParseFloat(num, 64)
+			d, err2 := strconv.ParseFloat(den, 64)
+			if err1 != nil || err2 != nil || d == 0 {
+				return 0, false
+			}
+			total += n / d
+			continue
+		}
+		if groupedRe.MatchString(part) {
+			part = strings.ReplaceAll(part, ",", "")
+		}
+		v, err := strconv.ParseFloat(strings.Replace(part, ",", ".", 1), 64)
+		if err != nil {
+			return 0, false
+		}
+		total += v
+	}
+	return total, true
+}
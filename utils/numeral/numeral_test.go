@@ -0,0 +1,29 @@
+package numeral
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		ok   bool
+	}{
+		{"2", 2, true},
+		{"1.5", 1.5, true},
+		{"1,5", 1.5, true},
+		{"1,000", 1000, true},
+		{"1,250.5", 1250.5, true},
+		{"2,000,000", 2000000, true},
+		{"1/2", 0.5, true},
+		{"2 1/2", 2.5, true},
+		{"1/0", 0, false},
+		{"", 0, false},
+		{"two", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.text)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v, want %v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package quantity
+
+import "math"
+
+// System is a set of units quantities can be rendered in.
+type System int
+
+const (
+	// Original leaves quantities as they were written.
+	Original System = iota
+	// Metric uses grams, millilitres and degrees Celsius, and weighs dry
+	// ingredients whose density is known.
+	Metric
+	// US uses ounces, pounds, cups and degrees Fahrenheit, and measures by
+	// volume ingredients whose density is known.
+	US
+)
+
+type dimension int
+
+const (
+	mass dimension = iota + 1
+	volume
+	temperature
+)
+
+// measures lists the units Convert understands, with the system they belong
+// to and their size in grams or millilitres. Counted units, such as cloves
+// or cans, are not in it and are never converted.
+var measures = map[string]struct {
+	system System
+	dim    dimension
+	factor float64
+}{
+	"mg":    {Metric, mass, 0.001},
+	"g":     {Metric, mass, 1},
+	"kg":    {Metric, mass, 1000},
+	"oz":    {US, mass, 28.349523125},
+	"lb":    {US, mass, 453.59237},
+	"ml":    {Metric, volume, 1},
+	"cl":    {Metric, volume, 10},
+	"dl":    {Metric, volume, 100},
+	"l":     {Metric, volume, 1000},
+	"tsp":   {US, volume, 4.92892159375},
+	"tbsp":  {US, volume, 14.78676478125},
+	"fl oz": {US, volume, 29.5735295625},
+	"cup":   {US, volume, 236.5882365},
+	"pt":    {US, volume, 473.176473},
+	"qt":    {US, volume, 946.352946},
+	"gal":   {US, volume, 3785.411784},
+	"°C":    {Metric, temperature, 0},
+	"°F":    {US, temperature, 0},
+}
+
+// Spoons are used on both sides of the Atlantic, so Convert leaves them be.
+var spoons = map[string]bool{"tsp": true, "tbsp": true}
+
+// Convert expresses q in system. ingredient is the name of what is being
+// measured; it picks the density used to turn "1 cup flour" into grams, or
+// grams of sugar into cups. Quantities already in system, and those Convert
+// cannot express in it such as counted items, are returned unchanged.
+func Convert(q Quantity, ingredient string, system System) Quantity {
+	if d, ok := DensityOf(ingredient); ok {
+		return ConvertWith(q, &d, system)
+	}
+	return ConvertWith(q, nil, system)
+}
+
+// ConvertWith is Convert with a known density, or none when d is nil.
+func ConvertWith(q Quantity, d *Density, system System) Quantity {
+	if system == Original || q.Amount == nil || spoons[q.Unit] {
+		return q
+	}
+	from, ok := measures[q.Unit]
+	if !ok || from.system == system {
+		return q
+	}
+	if from.dim == temperature {
+		return convertTemperature(q, system)
+	}
+
+	// everything below is in grams or millilitres
+	dim := from.dim
+	scale := from.factor
+	if d != nil && d.GPerML > 0 {
+		switch {
+		case system == Metric && dim == volume && d.Weighed:
+			dim, scale = mass, scale*d.GPerML
+		case system == US && dim == mass:
+			dim, scale = volume, scale/d.GPerML
+		}
+	}
+
+	unit := pick(system, dim, *q.Amount*scale)
+	to := measures[unit].factor
+	out := q
+	out.Unit = unit
+	out.Amount = round(*q.Amount*scale/to, unit)
+	if q.Max != nil {
+		out.Max = round(*q.Max*scale/to, unit)
+	}
+	return out
+}
+
+// pick returns the unit of system that reads best for base, an amount in
+// grams or millilitres.
+func pick(system System, dim dimension, base float64) string {
+	switch {
+	case system == Metric && dim == mass:
+		if base >= 1000 {
+			return "kg"
+		}
+		return "g"
+	case system == Metric:
+		if base >= 1000 {
+			return "l"
+		}
+		return "ml"
+	case dim == mass:
+		if base >= measures["lb"].factor {
+			return "lb"
+		}
+		return "oz"
+	}
+	switch {
+	case base < measures["tbsp"].factor:
+		return "tsp"
+	case base < measures["cup"].factor/4:
+		return "tbsp"
+	}
+	return "cup"
+}
+
+// round keeps the precision a cook can measure in unit: whole grams and
+// millilitres, steps of five above a hundred, and eighths of US units.
+func round(v float64, unit string) *float64 {
+	var r float64
+	switch unit {
+	case "g", "ml":
+		switch {
+		case v < 10:
+			r = math.Round(v*2) / 2
+		case v < 100:
+			r = math.Round(v)
+		default:
+			r = math.Round(v/5) * 5
+		}
+	case "kg", "l":
+		r = math.Round(v*100) / 100
+	case "oz":
+		r = math.Round(v*4) / 4
+	default:
+		r = math.Round(v*8) / 8
+	}
+	return &r
+}
+
+// convertTemperature turns Fahrenheit into Celsius for Metric and Celsius
+// into Fahrenheit for US, in steps of five degrees as oven dials show.
+func convertTemperature(q Quantity, system System) Quantity {
+	convert := func(v float64) *float64 {
+		if system == Metric {
+			v = (v - 32) * 5 / 9
+		} else {
+			v = v*9/5 + 32
+		}
+		r := math.Round(v/5) * 5
+		return &r
+	}
+
+	out := q
+	out.Unit = "°F"
+	if system == Metric {
+		out.Unit = "°C"
+	}
+	out.Amount = convert(*q.Amount)
+	if q.Max != nil {
+		out.Max = convert(*q.Max)
+	}
+	return out
+}
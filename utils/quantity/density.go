@@ -0,0 +1,104 @@
+package quantity
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Density is what converting between the weight and the volume of an
+// ingredient takes.
+type Density struct {
+	GPerML float64
+	// Weighed ingredients are measured on scales in metric kitchens;
+	// liquids keep being measured by volume.
+	Weighed bool
+}
+
+// densities are average values for common ingredients as they are usually
+// measured: spooned flour, packed brown sugar, and so on. An ingredient
+// matches the longest name found in it, so "brown sugar" wins over "sugar"
+// and "buttermilk" is not taken for butter.
+var densities = map[string]Density{
+	"flour":             {0.53, true},
+	"bread flour":       {0.55, true},
+	"whole wheat flour": {0.51, true},
+	"almond flour":      {0.40, true},
+	"cornstarch":        {0.54, true},
+	"sugar":             {0.85, true},
+	"brown sugar":       {0.93, true},
+	"powdered sugar":    {0.51, true},
+	"icing sugar":       {0.51, true},
+	"honey":             {1.42, true},
+	"maple syrup":       {1.32, false},
+	"butter":            {0.96, true},
+	"peanut butter":     {1.08, true},
+	"salt":              {1.22, true},
+	"baking powder":     {0.81, true},
+	"baking soda":       {0.92, true},
+	"cocoa":             {0.42, true},
+	"chocolate chips":   {0.72, true},
+	"rice":              {0.79, true},
+	"oats":              {0.38, true},
+	"yogurt":            {1.03, true},
+	"cheese":            {0.42, true},
+	"cream cheese":      {1.02, true},
+	"water":             {1.00, false},
+	"milk":              {1.03, false},
+	"buttermilk":        {1.03, false},
+	"cream":             {1.01, false},
+	"oil":               {0.92, false},
+	"vinegar":           {1.01, false},
+	"stock":             {1.00, false},
+	"broth":             {1.00, false},
+	"wine":              {0.99, false},
+}
+
+// densityNames are the keys of densities, longest first.
+var densityNames = func() []string {
+	names := make([]string, 0, len(densities))
+	for name := range densities {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(a, b int) bool {
+		if len(names[a]) != len(names[b]) {
+			return len(names[a]) > len(names[b])
+		}
+		return names[a] < names[b]
+	})
+	return names
+}()
+
+// DensityOf returns the average density of the ingredient named, if it is
+// a common one.
+func DensityOf(ingredient string) (Density, bool) {
+	ingredient = strings.ToLower(ingredient)
+	for _, name := range densityNames {
+		if containsWord(ingredient, name) {
+			return densities[name], true
+		}
+	}
+	return Density{}, false
+}
+
+// containsWord reports whether s holds word, or its plural, as a whole word.
+func containsWord(s, word string) bool {
+	for from := 0; ; {
+		n := strings.Index(s[from:], word)
+		if n < 0 {
+			return false
+		}
+		start, end := from+n, from+n+len(word)
+		if end < len(s) && s[end] == 's' {
+			end++
+		}
+		if (start == 0 || !isLetter(s, start-1)) && (end == len(s) || !isLetter(s, end)) {
+			return true
+		}
+		from = start + 1
+	}
+}
+
+func isLetter(s string, i int) bool {
+	return unicode.IsLetter(rune(s[i]))
+}
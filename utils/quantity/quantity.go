@@ -0,0 +1,254 @@
+// Package quantity reads the free-text quantities of ingredient lines, such
+// as "2 1/2 cups" or "½ tsp", into numbers and units that can be computed
+// with, and writes them back for display.
+package quantity
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ottolauncher/recipes/utils/numeral"
+)
+
+// Quantity is the structured form of a quantity. It is stored next to the
+// original text, which stays the reference.
+type Quantity struct {
+	// Amount is nil when the text names no number, as in "to taste".
+	Amount *float64 `json:"amount,omitempty" bson:"amount,omitempty"`
+	// Max is the upper bound of a range such as "2-3", in which case Amount
+	// is the lower one.
+	Max *float64 `json:"max,omitempty" bson:"max,omitempty"`
+	// Unit is canonical, "tbsp" whether the text said "T", "tbs" or
+	// "tablespoons"; empty for counted items such as "2 eggs".
+	Unit string `json:"unit,omitempty" bson:"unit,omitempty"`
+	// Note keeps whatever the parser did not understand.
+	Note string `json:"note,omitempty" bson:"note,omitempty"`
+}
+
+// Line is a whole ingredient line, "2 cups flour, sifted", split into its
+// quantity and the name of the ingredient.
+type Line struct {
+	Quantity
+	Name string
+}
+
+var (
+	// a comma followed by three digits groups thousands, "1,000"; followed
+	// by one or two it is a decimal comma, "1,5"
+	number   = numeral.Grouped + `|\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+|,\d{1,2}\b)?`
+	amountRe = regexp.MustCompile(`^(` + number + `)(?:\s*(?:-|to\b)\s*(` + number + `))?`)
+	articles = []string{"a ", "an "}
+)
+
+// Parse reads a quantity such as "2 1/2 cups", "½ tsp", "2-3 cloves" or
+// "a pinch". Text it cannot read ends up in the note, so nothing is lost.
+func Parse(text string) Quantity {
+	q, notes, rest := parse(normalize(text))
+	if rest = strings.TrimLeft(rest, ",;: "); rest != "" {
+		notes = append(notes, rest)
+	}
+	q.Note = strings.Join(notes, ", ")
+	return q
+}
+
+// ParseLine reads a whole ingredient line. The name is what follows the
+// quantity up to the first comma; the rest of the line and any
+// parenthesised remark go to the note.
+func ParseLine(text string) Line {
+	q, notes, rest := parse(normalize(text))
+
+	name := rest
+	if n := strings.IndexAny(rest, ",;"); n >= 0 {
+		name = rest[:n]
+		if note := strings.TrimSpace(rest[n+1:]); note != "" {
+			notes = append(notes, note)
+		}
+	}
+	if open := strings.Index(name, "("); open >= 0 {
+		if end := strings.Index(name[open:], ")"); end > 0 {
+			notes = append(notes, strings.TrimSpace(name[open+1:open+end]))
+			name = name[:open] + name[open+end+1:]
+		}
+	}
+	q.Note = strings.Join(notes, ", ")
+	return Line{Quantity: q, Name: strings.Join(strings.Fields(name), " ")}
+}
+
+// parse reads the amount and unit at the start of s, which is normalized,
+// and returns the remarks found in between and what follows them.
+func parse(s string) (Quantity, []string, string) {
+	var (
+		q     Quantity
+		notes []string
+	)
+
+	if m := amountRe.FindStringSubmatch(s); m != nil {
+		if v, ok := numeral.Parse(m[1]); ok {
+			q.Amount = &v
+			if v, ok := numeral.Parse(m[2]); ok {
+				q.Max = &v
+			}
+			s = strings.TrimSpace(s[len(m[0]):])
+		}
+	} else {
+		lower := strings.ToLower(s)
+		for _, a := range articles {
+			if strings.HasPrefix(lower, a) {
+				if _, n := matchUnit(strings.TrimSpace(s[len(a):])); n > 0 {
+					one := 1.0
+					q.Amount = &one
+					s = strings.TrimSpace(s[len(a):])
+				}
+				break
+			}
+		}
+	}
+
+	// "1 (14 oz) can tomatoes"
+	if strings.HasPrefix(s, "(") {
+		if end := strings.Index(s, ")"); end > 0 {
+			notes = append(notes, strings.TrimSpace(s[1:end]))
+			s = strings.TrimSpace(s[end+1:])
+		}
+	}
+
+	if unit, n := matchUnit(s); n > 0 {
+		q.Unit = unit
+		s = strings.TrimSpace(s[n:])
+		if strings.HasPrefix(strings.ToLower(s), "of ") {
+			s = strings.TrimSpace(s[3:])
+		}
+	}
+	return q, notes, s
+}
+
+// matchUnit returns the canonical unit s starts with and the length of its
+// spelling, preferring the longest one.
+func matchUnit(s string) (string, int) {
+	// a lone capital T is the usual shorthand for a tablespoon, and a lower
+	// case one for a teaspoon
+	for _, short := range []struct{ spelling, unit string }{{"T.", "tbsp"}, {"T", "tbsp"}, {"t.", "tsp"}, {"t", "tsp"}} {
+		if strings.HasPrefix(s, short.spelling) && boundary(s, len(short.spelling)) {
+			return short.unit, len(short.spelling)
+		}
+	}
+
+	lower := strings.ToLower(s)
+	var unit, spelling string
+	for alias, u := range units {
+		if len(alias) > len(spelling) && strings.HasPrefix(lower, alias) && boundary(lower, len(alias)) {
+			unit, spelling = u, alias
+		}
+	}
+	return unit, len(spelling)
+}
+
+func boundary(s string, n int) bool {
+	return n == len(s) || strings.ContainsRune(" ,.;:()", rune(s[n]))
+}
+
+// normalize rewrites Unicode fractions and dashes to their ASCII forms and
+// collapses white space, so "2½–3 cups" reads as "2 1/2 -3 cups".
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if f, ok := fractions[r]; ok {
+			b.WriteString(" " + f + " ")
+			continue
+		}
+		switch r {
+		case '⁄':
+			b.WriteRune('/')
+		case '–', '—':
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// String renders q for display: "2 ½ cups", "2–3 cloves", "1 tbsp, heaped",
+// "3 large" or just the note, as in "to taste".
+func (q Quantity) String() string {
+	var parts []string
+	if q.Amount != nil {
+		format := Format
+		if decimal[q.Unit] {
+			format = formatDecimal
+		}
+		amount := format(*q.Amount)
+		if q.Max != nil {
+			amount += "–" + format(*q.Max)
+		}
+		parts = append(parts, amount)
+	}
+	if q.Unit != "" {
+		unit := q.Unit
+		if plural, ok := plurals[unit]; ok && q.plural() {
+			unit = plural
+		}
+		parts = append(parts, unit)
+	}
+
+	s := strings.Join(parts, " ")
+	switch {
+	case q.Note == "":
+		return s
+	case s == "":
+		return q.Note
+	case q.Unit == "":
+		// "3 large"
+		return s + " " + q.Note
+	}
+	return s + ", " + q.Note
+}
+
+func (q Quantity) plural() bool {
+	return q.Amount != nil && (*q.Amount > 1 || q.Max != nil)
+}
+
+// Format writes an amount the way recipes do, with a Unicode fraction when
+// it is close to halves, thirds, quarters or eighths and with at most two
+// decimals otherwise.
+func Format(v float64) string {
+	whole := math.Floor(v)
+	frac := v - whole
+	switch {
+	case frac < 0.01:
+		return strconv.FormatFloat(whole, 'f', -1, 64)
+	case frac > 0.99:
+		return strconv.FormatFloat(whole+1, 'f', -1, 64)
+	}
+
+	for _, d := range []float64{2, 3, 4, 8} {
+		n := math.Round(frac * d)
+		if n == 0 || n == d || math.Abs(frac-n/d) > 0.01 {
+			continue
+		}
+		glyph := glyphs[fmt.Sprintf("%d/%d", int(n), int(d))]
+		if glyph == "" {
+			continue
+		}
+		if whole == 0 {
+			return glyph
+		}
+		return strconv.FormatFloat(whole, 'f', -1, 64) + " " + glyph
+	}
+	return formatDecimal(v)
+}
+
+func formatDecimal(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+var glyphs = func() map[string]string {
+	m := make(map[string]string, len(fractions))
+	for r, f := range fractions {
+		m[f] = string(r)
+	}
+	return m
+}()
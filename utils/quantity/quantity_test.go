@@ -0,0 +1,171 @@
+package quantity
+
+import (
+	"strings"
+	"testing"
+)
+
+func f(v float64) *float64 { return &v }
+
+func equal(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	d := *a - *b
+	return d < 1e-9 && d > -1e-9
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want Quantity
+	}{
+		{"2 cups", Quantity{Amount: f(2), Unit: "cup"}},
+		{"2 1/2 cups", Quantity{Amount: f(2.5), Unit: "cup"}},
+		{"½ tsp", Quantity{Amount: f(0.5), Unit: "tsp"}},
+		{"2½ cups", Quantity{Amount: f(2.5), Unit: "cup"}},
+		{"1.5 kg", Quantity{Amount: f(1.5), Unit: "kg"}},
+		{"1,5 kg", Quantity{Amount: f(1.5), Unit: "kg"}},
+		{"1,000 g", Quantity{Amount: f(1000), Unit: "g"}},
+		{"1,250.5 ml", Quantity{Amount: f(1250.5), Unit: "ml"}},
+		{"2,000,000 g", Quantity{Amount: f(2000000), Unit: "g"}},
+		{"1,000-1,500 g", Quantity{Amount: f(1000), Max: f(1500), Unit: "g"}},
+		{"12,5 g", Quantity{Amount: f(12.5), Unit: "g"}},
+		{"2-3 cloves", Quantity{Amount: f(2), Max: f(3), Unit: "clove"}},
+		{"2 to 3 cloves", Quantity{Amount: f(2), Max: f(3), Unit: "clove"}},
+		{"1 T", Quantity{Amount: f(1), Unit: "tbsp"}},
+		{"1 t", Quantity{Amount: f(1), Unit: "tsp"}},
+		{"3 tablespoons", Quantity{Amount: f(3), Unit: "tbsp"}},
+		{"a pinch", Quantity{Amount: f(1), Unit: "pinch"}},
+		{"3 large", Quantity{Amount: f(3), Note: "large"}},
+		{"1 (14 oz) can", Quantity{Amount: f(1), Unit: "can", Note: "14 oz"}},
+		{"to taste", Quantity{Note: "to taste"}},
+		{"", Quantity{}},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.text)
+		if !equal(got.Amount, tt.want.Amount) || !equal(got.Max, tt.want.Max) || got.Unit != tt.want.Unit || got.Note != tt.want.Note {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.text, show(got), show(tt.want))
+		}
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		text   string
+		amount *float64
+		unit   string
+		name   string
+		note   string
+	}{
+		{"2 cups flour, sifted", f(2), "cup", "flour", "sifted"},
+		{"1 (14 oz) can tomatoes", f(1), "can", "tomatoes", "14 oz"},
+		{"3 eggs (large)", f(3), "", "eggs", "large"},
+		{"salt", nil, "", "salt", ""},
+		{"200 g of butter", f(200), "g", "butter", ""},
+	}
+	for _, tt := range tests {
+		got := ParseLine(tt.text)
+		if !equal(got.Amount, tt.amount) || got.Unit != tt.unit || got.Name != tt.name || got.Note != tt.note {
+			t.Errorf("ParseLine(%q) = %+v %q, want amount %v unit %q name %q note %q", tt.text, show(got.Quantity), got.Name, deref(tt.amount), tt.unit, tt.name, tt.note)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		q    Quantity
+		want string
+	}{
+		{Quantity{Amount: f(2.5), Unit: "cup"}, "2 ½ cups"},
+		{Quantity{Amount: f(1), Unit: "cup"}, "1 cup"},
+		{Quantity{Amount: f(2), Max: f(3), Unit: "clove"}, "2–3 cloves"},
+		{Quantity{Amount: f(1), Unit: "tbsp", Note: "heaped"}, "1 tbsp, heaped"},
+		{Quantity{Amount: f(3), Note: "large"}, "3 large"},
+		{Quantity{Note: "to taste"}, "to taste"},
+	}
+	for _, tt := range tests {
+		if got := tt.q.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", show(tt.q), got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{2, "2"},
+		{0.5, "½"},
+		{1.25, "1 ¼"},
+		{1.0 / 3, "⅓"},
+		{0.125, "⅛"},
+		{1.999, "2"},
+		{1.3, "1.3"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.v); got != tt.want {
+			t.Errorf("Format(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+// show prints the amounts of q rather than their addresses.
+func show(q Quantity) map[string]interface{} {
+	return map[string]interface{}{"amount": deref(q.Amount), "max": deref(q.Max), "unit": q.Unit, "note": q.Note}
+}
+
+func deref(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		q      string
+		factor float64
+		want   string
+	}{
+		{"2 eggs", 1.5, "3 eggs"},
+		{"1 egg", 0.25, "1 egg"},
+		{"1/2 lemon", 3, "1 ½ lemon"},
+		{"200 g", 2, "400 g"},
+		{"180 °C", 2, "180 °C"},
+		{"to taste", 2, "to taste"},
+	}
+	for _, tt := range tests {
+		if got := Scale(Parse(tt.q), tt.factor).String(); got != tt.want {
+			t.Errorf("Scale(%q, %v) = %q, want %q", tt.q, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestSum(t *testing.T) {
+	tests := []struct {
+		qs     []string
+		system System
+		want   []string
+	}{
+		{[]string{"200 g", "1 kg"}, Original, []string{"1.2 kg"}},
+		{[]string{"1 tbsp", "1 tsp"}, Original, []string{"1 ⅜ tbsp"}},
+		{[]string{"2 eggs", "3 eggs"}, Original, []string{"5"}},
+		{[]string{"2-3 cloves", "1 clove"}, Original, []string{"4 cloves"}},
+		{[]string{"to taste", "100 ml", "to taste"}, Metric, []string{"100 ml", "to taste"}},
+	}
+	for _, tt := range tests {
+		var qs []Quantity
+		for _, q := range tt.qs {
+			qs = append(qs, Parse(q))
+		}
+		var got []string
+		for _, q := range Sum(qs, nil, tt.system) {
+			got = append(got, q.String())
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("Sum(%q) = %q, want %q", tt.qs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package quantity
+
+import "math"
+
+// Scale multiplies q by factor, as when a recipe for four is cooked for six.
+// Results are rounded to what a cook can measure: whole items for counted
+// ingredients, so there are no 1.5 eggs, and the steps Convert rounds to
+// for measured ones. Temperatures and quantities without a number stay as
+// they are.
+func Scale(q Quantity, factor float64) Quantity {
+	if q.Amount == nil || factor == 1 || measures[q.Unit].dim == temperature {
+		return q
+	}
+	out := q
+	out.Amount = scale(*q.Amount, factor, q.Unit)
+	if q.Max != nil {
+		out.Max = scale(*q.Max, factor, q.Unit)
+	}
+	return out
+}
+
+func scale(v, factor float64, unit string) *float64 {
+	scaled := v * factor
+	if _, measured := measures[unit]; measured {
+		r := round(scaled, unit)
+		if *r == 0 {
+			// too little for the usual steps, but not nothing
+			r = &scaled
+		}
+		return r
+	}
+
+	// counted items come whole, unless the recipe already asked for half a
+	// lemon, and never drop to none
+	step := 1.0
+	if v != math.Trunc(v) {
+		step = 0.5
+	}
+	r := math.Max(step, math.Round(scaled/step)*step)
+	return &r
+}
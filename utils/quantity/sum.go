@@ -0,0 +1,84 @@
+package quantity
+
+// Sum adds up quantities of one ingredient, as a shopping list does. Each
+// is first converted to system with density d, which may be nil. Weights
+// are then added together, and so are volumes, each written in the unit of
+// system that reads best; with Original that is the system of the first
+// unit of its kind, and spoons stay spoons when nothing bigger was asked
+// for. Counted items are added per unit. A range counts as its upper bound,
+// what to buy for. Quantities without an amount, such as "to taste", come
+// last, once each.
+func Sum(qs []Quantity, d *Density, system System) []Quantity {
+	type total struct {
+		base   float64
+		system System
+		spoons bool
+	}
+	var (
+		totals  = map[dimension]*total{}
+		counted = map[string]float64{}
+		units   []string
+		notes   []string
+		seen    = map[string]bool{}
+	)
+	for _, q := range qs {
+		q = ConvertWith(q, d, system)
+		if q.Amount == nil {
+			if !seen[q.Note] && q.Note != "" {
+				seen[q.Note] = true
+				notes = append(notes, q.Note)
+			}
+			continue
+		}
+		amount := *q.Amount
+		if q.Max != nil {
+			amount = *q.Max
+		}
+
+		m, measured := measures[q.Unit]
+		if !measured || m.dim == temperature {
+			if _, ok := counted[q.Unit]; !ok {
+				units = append(units, q.Unit)
+			}
+			counted[q.Unit] += amount
+			continue
+		}
+		t := totals[m.dim]
+		if t == nil {
+			t = &total{system: system, spoons: true}
+			if system == Original {
+				t.system = m.system
+			}
+			totals[m.dim] = t
+		}
+		t.base += amount * m.factor
+		t.spoons = t.spoons && spoons[q.Unit]
+	}
+
+	var out []Quantity
+	for _, dim := range []dimension{mass, volume} {
+		t := totals[dim]
+		if t == nil {
+			continue
+		}
+		target := t.system
+		if t.spoons {
+			target = US
+		}
+		unit := pick(target, dim, t.base)
+		exact := t.base / measures[unit].factor
+		amount := round(exact, unit)
+		if *amount == 0 {
+			amount = &exact
+		}
+		out = append(out, Quantity{Amount: amount, Unit: unit})
+	}
+	for _, unit := range units {
+		amount := counted[unit]
+		out = append(out, Quantity{Amount: &amount, Unit: unit})
+	}
+	for _, note := range notes {
+		out = append(out, Quantity{Note: note})
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package quantity
+
+// units maps every spelling the parser accepts, lowercased, to the
+// canonical unit stored with an ingredient. Multi-word spellings are tried
+// before single words.
+var units = map[string]string{
+	"teaspoon": "tsp", "teaspoons": "tsp", "tsp": "tsp", "tsps": "tsp", "tsp.": "tsp",
+	"tablespoon": "tbsp", "tablespoons": "tbsp", "tbsp": "tbsp", "tbsps": "tbsp", "tbs": "tbsp", "tbl": "tbsp", "tbsp.": "tbsp",
+	"cup": "cup", "cups": "cup", "c": "cup", "c.": "cup",
+	"fluid ounce": "fl oz", "fluid ounces": "fl oz", "fl oz": "fl oz", "fl. oz.": "fl oz", "fl.oz": "fl oz", "floz": "fl oz",
+	"pint": "pt", "pints": "pt", "pt": "pt", "pts": "pt",
+	"quart": "qt", "quarts": "qt", "qt": "qt", "qts": "qt",
+	"gallon": "gal", "gallons": "gal", "gal": "gal", "gals": "gal",
+	"milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml", "ml": "ml",
+	"centiliter": "cl", "centiliters": "cl", "centilitre": "cl", "centilitres": "cl", "cl": "cl",
+	"deciliter": "dl", "deciliters": "dl", "decilitre": "dl", "decilitres": "dl", "dl": "dl",
+	"liter": "l", "liters": "l", "litre": "l", "litres": "l", "l": "l",
+	"milligram": "mg", "milligrams": "mg", "mg": "mg",
+	"gram": "g", "grams": "g", "gramme": "g", "grammes": "g", "g": "g", "gr": "g",
+	"kilogram": "kg", "kilograms": "kg", "kilo": "kg", "kilos": "kg", "kg": "kg",
+	"ounce": "oz", "ounces": "oz", "oz": "oz", "oz.": "oz",
+	"pound": "lb", "pounds": "lb", "lb": "lb", "lbs": "lb", "lb.": "lb", "lbs.": "lb",
+	"°c": "°C", "celsius": "°C", "degrees c": "°C", "degrees celsius": "°C", "deg c": "°C",
+	"°f": "°F", "fahrenheit": "°F", "degrees f": "°F", "degrees fahrenheit": "°F", "deg f": "°F",
+	"pinch": "pinch", "pinches": "pinch",
+	"dash": "dash", "dashes": "dash",
+	"clove": "clove", "cloves": "clove",
+	"can": "can", "cans": "can", "tin": "can", "tins": "can",
+	"slice": "slice", "slices": "slice",
+	"piece": "piece", "pieces": "piece", "pc": "piece", "pcs": "piece",
+	"package": "package", "packages": "package", "pkg": "package", "packet": "package", "packets": "package",
+	"stick": "stick", "sticks": "stick",
+	"bunch": "bunch", "bunches": "bunch",
+	"sprig": "sprig", "sprigs": "sprig",
+	"handful": "handful", "handfuls": "handful",
+}
+
+// plurals lists the units spelled out as words, which take a plural.
+// Abbreviated units are displayed as they are.
+var plurals = map[string]string{
+	"cup":     "cups",
+	"pinch":   "pinches",
+	"dash":    "dashes",
+	"clove":   "cloves",
+	"can":     "cans",
+	"slice":   "slices",
+	"piece":   "pieces",
+	"package": "packages",
+	"stick":   "sticks",
+	"bunch":   "bunches",
+	"sprig":   "sprigs",
+	"handful": "handfuls",
+}
+
+// decimal lists the units whose amounts are written as decimals, "1.5 l",
+// rather than with fractions.
+var decimal = map[string]bool{
+	"mg": true, "g": true, "kg": true,
+	"ml": true, "cl": true, "dl": true, "l": true,
+	"°C": true, "°F": true,
+}
+
+// fractions are the Unicode vulgar fractions the parser reads and the
+// display writes.
+var fractions = map[rune]string{
+	'½': "1/2",
+	'⅓': "1/3",
+	'⅔': "2/3",
+	'¼': "1/4",
+	'¾': "3/4",
+	'⅕': "1/5",
+	'⅖': "2/5",
+	'⅗': "3/5",
+	'⅘': "4/5",
+	'⅙': "1/6",
+	'⅚': "5/6",
+	'⅛': "1/8",
+	'⅜': "3/8",
+	'⅝': "5/8",
+	'⅞': "7/8",
+}